@@ -1,8 +1,10 @@
 package agora
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/GyroTools/gtagora-connector-go/agora/models"
@@ -15,21 +17,64 @@ type Agora struct {
 }
 
 func Ping(url string) error {
+	return PingContext(context.Background(), url)
+}
+
+func PingContext(ctx context.Context, url string) error {
 	client := http.NewClient(url, "", false)
-	return client.Ping()
+	return client.PingContext(ctx)
 }
 
 func (a *Agora) SetTimeout(timout time.Duration) {
 	a.Client.SetDefaultTimeout(timout)
 }
 
+func (a *Agora) SetPageSize(pageSize int) {
+	a.Client.SetDefaultPageSize(pageSize)
+}
+
+func (a *Agora) SetTransportOptions(opts http.TransportOptions) {
+	a.Client.SetTransportOptions(opts)
+}
+
+func (a *Agora) SetTLSOptions(opts http.TLSOptions) error {
+	return a.Client.SetTLSOptions(opts)
+}
+
+func (a *Agora) SetRetryPolicy(policy http.RetryPolicy) {
+	a.Client.SetRetryPolicy(policy)
+}
+
+// SetRateLimit limits the requests of this instance and all models created from it. requestsPerSecond <= 0 disables the limit
+func (a *Agora) SetRateLimit(requestsPerSecond float64, burst int) {
+	a.Client.SetRateLimit(requestsPerSecond, burst)
+}
+
+// SetMaxInFlight limits the number of concurrent requests of this instance and all models created from it. maxInFlight <= 0 disables the limit
+func (a *Agora) SetMaxInFlight(maxInFlight int) {
+	a.Client.SetMaxInFlight(maxInFlight)
+}
+
+// Use adds middlewares which are applied to every request of this instance and all models created from it
+func (a *Agora) Use(middlewares ...http.Middleware) {
+	a.Client.Use(middlewares...)
+}
+
 func (a *Agora) GetApiKey() (string, error) {
-	return a.Client.GetApiKey()
+	return a.GetApiKeyContext(context.Background())
+}
+
+func (a *Agora) GetApiKeyContext(ctx context.Context) (string, error) {
+	return a.Client.GetApiKeyContext(ctx)
 }
 
 func (a *Agora) GetMyAgora() (*models.Project, error) {
+	return a.GetMyAgoraContext(context.Background())
+}
+
+func (a *Agora) GetMyAgoraContext(ctx context.Context) (*models.Project, error) {
 	var project models.Project
-	err := a.Client.GetAndParse(fmt.Sprintf("%s%s/", models.ProjectURL, "myagora"), &project)
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%s/", models.ProjectURL, "myagora"), &project)
 	if err != nil {
 		return nil, err
 	}
@@ -37,18 +82,43 @@ func (a *Agora) GetMyAgora() (*models.Project, error) {
 }
 
 func (a *Agora) GetProjects() ([]models.Project, error) {
-	var projects []models.Project
-	err := a.Client.GetAndParse(models.ProjectURL, &projects)
+	return a.GetProjectsContext(context.Background())
+}
+
+func (a *Agora) GetProjectsContext(ctx context.Context) ([]models.Project, error) {
+	return http.NewPaginator[models.Project](ctx, a.Client, models.ProjectURL, 0).All()
+}
+
+func (a *Agora) GetProject(id int) (*models.Project, error) {
+	return a.GetProjectContext(context.Background(), id)
+}
+
+func (a *Agora) GetProjectContext(ctx context.Context, id int) (*models.Project, error) {
+	var project models.Project
+
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.ProjectURL, id), &project)
 	if err != nil {
 		return nil, err
 	}
-	return projects, nil
+	return &project, nil
 }
 
-func (a *Agora) GetProject(id int) (*models.Project, error) {
-	var project models.Project
+// CreateProject creates a new project. The current user becomes its owner
+func (a *Agora) CreateProject(name string, description string) (*models.Project, error) {
+	return a.CreateProjectContext(context.Background(), name, description)
+}
+
+func (a *Agora) CreateProjectContext(ctx context.Context, name string, description string) (*models.Project, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("the project name must not be empty")
+	}
+	data := map[string]string{"name": name}
+	if description != "" {
+		data["description"] = description
+	}
 
-	err := a.Client.GetAndParse(fmt.Sprintf("%s%d/", models.ProjectURL, id), &project)
+	var project models.Project
+	err := a.Client.DoContext(ctx, "POST", models.ProjectURL, data, &project)
 	if err != nil {
 		return nil, err
 	}
@@ -56,19 +126,69 @@ func (a *Agora) GetProject(id int) (*models.Project, error) {
 }
 
 func (a *Agora) GetStudy(id int) (*models.Study, error) {
+	return a.GetStudyContext(context.Background(), id)
+}
+
+func (a *Agora) GetStudyContext(ctx context.Context, id int) (*models.Study, error) {
 	var study models.Study
 
-	err := a.Client.GetAndParse(fmt.Sprintf("%s%d/", models.StudyURL, id), &study)
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.StudyURL, id), &study)
 	if err != nil {
 		return nil, err
 	}
 	return &study, nil
 }
 
+func (a *Agora) GetSeries(id int) (*models.Series, error) {
+	return a.GetSeriesContext(context.Background(), id)
+}
+
+func (a *Agora) GetSeriesContext(ctx context.Context, id int) (*models.Series, error) {
+	var series models.Series
+
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.SeriesURL, id), &series)
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (a *Agora) GetDataset(id int) (*models.Dataset, error) {
+	return a.GetDatasetContext(context.Background(), id)
+}
+
+func (a *Agora) GetDatasetContext(ctx context.Context, id int) (*models.Dataset, error) {
+	var dataset models.Dataset
+
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.DatasetURL, id), &dataset)
+	if err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+func (a *Agora) GetDatafile(id int) (*models.Datafile, error) {
+	return a.GetDatafileContext(context.Background(), id)
+}
+
+func (a *Agora) GetDatafileContext(ctx context.Context, id int) (*models.Datafile, error) {
+	var datafile models.Datafile
+
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.DatafileURL, id), &datafile)
+	if err != nil {
+		return nil, err
+	}
+	return &datafile, nil
+}
+
 func (a *Agora) GetPatient(id int) (*models.Patient, error) {
+	return a.GetPatientContext(context.Background(), id)
+}
+
+func (a *Agora) GetPatientContext(ctx context.Context, id int) (*models.Patient, error) {
 	var patient models.Patient
 
-	err := a.Client.GetAndParse(fmt.Sprintf("%s%d/", models.PatientURL, id), &patient)
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.PatientURL, id), &patient)
 	if err != nil {
 		return nil, err
 	}
@@ -76,9 +196,13 @@ func (a *Agora) GetPatient(id int) (*models.Patient, error) {
 }
 
 func (a *Agora) GetFolder(id int) (*models.Folder, error) {
+	return a.GetFolderContext(context.Background(), id)
+}
+
+func (a *Agora) GetFolderContext(ctx context.Context, id int) (*models.Folder, error) {
 	var folder models.Folder
 
-	err := a.Client.GetAndParse(fmt.Sprintf("%s%d/", models.FolderURL, id), &folder)
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.FolderURL, id), &folder)
 	if err != nil {
 		return nil, err
 	}
@@ -86,19 +210,209 @@ func (a *Agora) GetFolder(id int) (*models.Folder, error) {
 }
 
 func (a *Agora) GetFolderItem(id int) (*models.FolderItem, error) {
+	return a.GetFolderItemContext(context.Background(), id)
+}
+
+func (a *Agora) GetFolderItemContext(ctx context.Context, id int) (*models.FolderItem, error) {
 	var folderItem models.FolderItem
 
-	err := a.Client.GetAndParse(fmt.Sprintf("%s%d/", models.FolderItemURL, id), &folderItem)
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.FolderItemURL, id), &folderItem)
 	if err != nil {
 		return nil, err
 	}
 	return &folderItem, nil
 }
 
+// GetCurrentUser returns the user which is authenticated by the connection
+func (a *Agora) GetCurrentUser() (*models.User, error) {
+	return a.GetCurrentUserContext(context.Background())
+}
+
+func (a *Agora) GetCurrentUserContext(ctx context.Context) (*models.User, error) {
+	var user models.User
+
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%s/", models.UserURL, "current"), &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (a *Agora) GetUsers() ([]models.User, error) {
+	return a.GetUsersContext(context.Background())
+}
+
+func (a *Agora) GetUsersContext(ctx context.Context) ([]models.User, error) {
+	return http.NewPaginator[models.User](ctx, a.Client, models.UserURL, 0).All()
+}
+
+func (a *Agora) GetUser(id int) (*models.User, error) {
+	return a.GetUserContext(context.Background(), id)
+}
+
+func (a *Agora) GetUserContext(ctx context.Context, id int) (*models.User, error) {
+	var user models.User
+
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.UserURL, id), &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (a *Agora) GetGroups() ([]models.Group, error) {
+	return a.GetGroupsContext(context.Background())
+}
+
+func (a *Agora) GetGroupsContext(ctx context.Context) ([]models.Group, error) {
+	return http.NewPaginator[models.Group](ctx, a.Client, models.GroupURL, 0).All()
+}
+
+func (a *Agora) GetGroup(id int) (*models.Group, error) {
+	return a.GetGroupContext(context.Background(), id)
+}
+
+func (a *Agora) GetGroupContext(ctx context.Context, id int) (*models.Group, error) {
+	var group models.Group
+
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.GroupURL, id), &group)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (a *Agora) GetTasks() ([]models.Task, error) {
+	return a.GetTasksContext(context.Background())
+}
+
+func (a *Agora) GetTasksContext(ctx context.Context) ([]models.Task, error) {
+	return http.NewPaginator[models.Task](ctx, a.Client, models.TaskURL, 0).All()
+}
+
+func (a *Agora) GetTask(id int) (*models.Task, error) {
+	return a.GetTaskContext(context.Background(), id)
+}
+
+func (a *Agora) GetTaskContext(ctx context.Context, id int) (*models.Task, error) {
+	var task models.Task
+
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.TaskURL, id), &task)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (a *Agora) GetTaskInfo(id int) (*models.TaskInfo, error) {
+	return a.GetTaskInfoContext(context.Background(), id)
+}
+
+func (a *Agora) GetTaskInfoContext(ctx context.Context, id int) (*models.TaskInfo, error) {
+	var taskInfo models.TaskInfo
+
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.TaskInfoURL, id), &taskInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &taskInfo, nil
+}
+
+func (a *Agora) GetHosts() ([]models.Host, error) {
+	return a.GetHostsContext(context.Background())
+}
+
+func (a *Agora) GetHostsContext(ctx context.Context) ([]models.Host, error) {
+	return http.NewPaginator[models.Host](ctx, a.Client, models.HostURL, 0).All()
+}
+
+func (a *Agora) GetHost(id int) (*models.Host, error) {
+	return a.GetHostContext(context.Background(), id)
+}
+
+func (a *Agora) GetHostContext(ctx context.Context, id int) (*models.Host, error) {
+	var host models.Host
+
+	err := a.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", models.HostURL, id), &host)
+	if err != nil {
+		return nil, err
+	}
+	return &host, nil
+}
+
+func (a *Agora) GetTags() ([]models.Tag, error) {
+	return a.GetTagsContext(context.Background())
+}
+
+func (a *Agora) GetTagsContext(ctx context.Context) ([]models.Tag, error) {
+	return http.NewPaginator[models.Tag](ctx, a.Client, models.TagURL, 0).All()
+}
+
+func (a *Agora) CreateTag(label string, color string) (*models.Tag, error) {
+	return a.CreateTagContext(context.Background(), label, color)
+}
+
+func (a *Agora) CreateTagContext(ctx context.Context, label string, color string) (*models.Tag, error) {
+	if strings.TrimSpace(label) == "" {
+		return nil, errors.New("the tag label must not be empty")
+	}
+	data := map[string]string{"label": label}
+	if color != "" {
+		data["color"] = color
+	}
+
+	var tag models.Tag
+	err := a.Client.DoContext(ctx, "POST", models.TagURL, data, &tag)
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindByTag returns all objects with the tag. contentType is one of the models.ContentType constants, an empty
+// contentType returns objects of all types
+func (a *Agora) FindByTag(tag *models.Tag, contentType string) ([]models.TypedObject, error) {
+	return a.FindByTagContext(context.Background(), tag, contentType)
+}
+
+func (a *Agora) FindByTagContext(ctx context.Context, tag *models.Tag, contentType string) ([]models.TypedObject, error) {
+	// the tag might not have been fetched with this instance
+	curTag := *tag
+	curTag.Client = a.Client
+	return curTag.GetObjectsContext(ctx, contentType)
+}
+
+// Search returns all objects matching the query. The results are typed like the content objects of folder items. opts can be nil
+func (a *Agora) Search(query string, opts *models.SearchOptions) ([]models.TypedObject, error) {
+	return a.SearchContext(context.Background(), query, opts)
+}
+
+func (a *Agora) SearchContext(ctx context.Context, query string, opts *models.SearchOptions) ([]models.TypedObject, error) {
+	return a.IterateSearch(ctx, query, opts, 0).All()
+}
+
+// IterateSearch returns an iterator which fetches the search results page by page
+func (a *Agora) IterateSearch(ctx context.Context, query string, opts *models.SearchOptions, pageSize int) *http.Paginator[models.TypedObject] {
+	return models.NewSearch(ctx, a.Client, query, opts, pageSize)
+}
+
+// QueryByParameter returns all datasets whose parameters match the filter, e.g. "EchoTime < 5 and ScanMode = 3D"
+func (a *Agora) QueryByParameter(filter string) ([]models.Dataset, error) {
+	return a.QueryByParameterContext(context.Background(), filter)
+}
+
+func (a *Agora) QueryByParameterContext(ctx context.Context, filter string) ([]models.Dataset, error) {
+	return models.QueryByParameter(ctx, a.Client, filter)
+}
+
 func (a *Agora) NewImportPackage() (*models.ImportPackage, error) {
+	return a.NewImportPackageContext(context.Background())
+}
+
+func (a *Agora) NewImportPackageContext(ctx context.Context) (*models.ImportPackage, error) {
 	var importPackage models.ImportPackage
 
-	err := a.Client.PostAndParse(fmt.Sprintf("%s", models.ImportPackageURL), nil, &importPackage)
+	err := a.Client.PostAndParseContext(ctx, fmt.Sprintf("%s", models.ImportPackageURL), nil, &importPackage)
 	if err != nil {
 		return nil, err
 	}
@@ -110,36 +424,107 @@ func NewAgora(url string, apiKey string, verifyCert bool) *Agora {
 	return &Agora{Client: http.NewClient(url, apiKey, verifyCert)}
 }
 
-func Create(url string, apiKey string, verifyCertificate bool) (*Agora, error) {
-	url, err := utils.ValidateURL(url)
-	if err != nil {
-		return nil, errors.New("invalid url")
+func NewAgoraWithConnection(connection http.Connection) *Agora {
+	return &Agora{Client: http.NewClientWithConnection(connection)}
+}
+
+// CreateOption configures the client of a new Agora instance before the connection is checked
+type CreateOption func(client *http.Client) error
+
+// WithTLSOptions applies the TLS options, e.g. for a private CA or mutual TLS
+func WithTLSOptions(opts http.TLSOptions) CreateOption {
+	return func(client *http.Client) error {
+		return client.SetTLSOptions(opts)
 	}
-	agora := NewAgora(url, apiKey, verifyCertificate)
+}
 
-	err = agora.Client.CheckConnection()
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("cannot connect to Agora: %s", err.Error()))
+func WithTransportOptions(opts http.TransportOptions) CreateOption {
+	return func(client *http.Client) error {
+		client.SetTransportOptions(opts)
+		return nil
+	}
+}
+
+func WithRetryPolicy(policy http.RetryPolicy) CreateOption {
+	return func(client *http.Client) error {
+		client.SetRetryPolicy(policy)
+		return nil
 	}
-	return agora, nil
 }
 
-func CreateWithPassword(url string, username string, password string, verifyCertificate bool) (*Agora, error) {
+func WithTimeout(timeout time.Duration) CreateOption {
+	return func(client *http.Client) error {
+		client.SetDefaultTimeout(timeout)
+		return nil
+	}
+}
+
+func WithMiddleware(middlewares ...http.Middleware) CreateOption {
+	return func(client *http.Client) error {
+		client.Use(middlewares...)
+		return nil
+	}
+}
+
+func applyOptions(client *http.Client, options []CreateOption) error {
+	for _, option := range options {
+		if err := option(client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Create(url string, apiKey string, verifyCertificate bool, options ...CreateOption) (*Agora, error) {
+	return CreateContext(context.Background(), url, apiKey, verifyCertificate, options...)
+}
+
+func CreateContext(ctx context.Context, url string, apiKey string, verifyCertificate bool, options ...CreateOption) (*Agora, error) {
 	url, err := utils.ValidateURL(url)
 	if err != nil {
-		return nil, errors.New("invalid url")
+		return nil, fmt.Errorf("invalid url: %w", err)
 	}
-	passwordClient := http.NewPasswordClient(url, username, password, verifyCertificate)
-	apiKey, err := passwordClient.GetApiKey()
+	return CreateWithConnectionContext(ctx, http.NewApiKeyConnection(url, apiKey, verifyCertificate), options...)
+}
+
+func CreateWithPassword(url string, username string, password string, verifyCertificate bool, options ...CreateOption) (*Agora, error) {
+	return CreateWithPasswordContext(context.Background(), url, username, password, verifyCertificate, options...)
+}
+
+func CreateWithPasswordContext(ctx context.Context, url string, username string, password string, verifyCertificate bool, options ...CreateOption) (*Agora, error) {
+	url, err := utils.ValidateURL(url)
 	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	passwordConnection := http.NewPasswordConnection(url, username, password, verifyCertificate)
+	passwordClient := http.NewClientWithConnection(passwordConnection)
+	if err := applyOptions(passwordClient, options); err != nil {
+		return nil, err
+	}
+	apiKey, err := passwordClient.GetApiKeyContext(ctx)
+	if errors.Is(err, http.ErrNotFound) {
+		// the user has no api key: keep using the password
+		return CreateWithConnectionContext(ctx, passwordConnection, options...)
+	} else if err != nil {
 		return nil, err
 	}
+	return CreateWithConnectionContext(ctx, http.NewApiKeyConnection(url, apiKey, verifyCertificate), options...)
+}
+
+// CreateWithConnection creates an Agora instance with a custom authentication and checks the connection
+func CreateWithConnection(connection http.Connection, options ...CreateOption) (*Agora, error) {
+	return CreateWithConnectionContext(context.Background(), connection, options...)
+}
 
-	agora := NewAgora(url, apiKey, verifyCertificate)
+func CreateWithConnectionContext(ctx context.Context, connection http.Connection, options ...CreateOption) (*Agora, error) {
+	agora := NewAgoraWithConnection(connection)
+	if err := applyOptions(agora.Client, options); err != nil {
+		return nil, err
+	}
 
-	err = agora.Client.CheckConnection()
+	err := agora.Client.CheckConnectionContext(ctx)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("cannot connect to Agora: %s", err.Error()))
+		return nil, fmt.Errorf("cannot connect to Agora: %w", err)
 	}
 	return agora, nil
 }
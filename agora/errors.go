@@ -0,0 +1,14 @@
+package agora
+
+import "github.com/GyroTools/gtagora-connector-go/internals/http"
+
+// APIError is returned whenever Agora answers with an unexpected status code
+type APIError = http.APIError
+
+var (
+	ErrNotFound          = http.ErrNotFound
+	ErrUnauthorized      = http.ErrUnauthorized
+	ErrForbidden         = http.ErrForbidden
+	ErrConflict          = http.ErrConflict
+	ErrServerUnavailable = http.ErrServerUnavailable
+)
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const (
+	ContentTypeProject = "project"
+	ContentTypeFolder  = "folder"
+	ContentTypePatient = "patient"
+	ContentTypeExam    = "exam"
+	ContentTypeSeries  = "series"
+	ContentTypeDataset = "dataset"
+)
+
+// ContentObject is a *Project, *Folder, *Patient, *Study, *Series or *Dataset depending on the content type. Unknown
+// content types are decoded into a map[string]interface{}
+type ContentObject interface{}
+
+// TypedObject is an object of any content type, e.g. a task result or an object found by tag
+type TypedObject struct {
+	ContentType   string        `json:"content_type"`
+	ObjectID      int           `json:"object_id"`
+	ContentObject ContentObject `json:"content_object"`
+
+	http.BaseModel
+}
+
+// Linkable is implemented by all objects which are referenced by content type and id, e.g. to place them in a folder
+// or to tag them
+type Linkable interface {
+	LinkRef() (contentType string, objectID int)
+}
+
+func (project *Project) LinkRef() (string, int) { return ContentTypeProject, project.ID }
+func (folder *Folder) LinkRef() (string, int)   { return ContentTypeFolder, folder.ID }
+func (patient *Patient) LinkRef() (string, int) { return ContentTypePatient, patient.ID }
+func (study *Study) LinkRef() (string, int)     { return ContentTypeExam, study.ID }
+func (series *Series) LinkRef() (string, int)   { return ContentTypeSeries, series.ID }
+func (dataset *Dataset) LinkRef() (string, int) { return ContentTypeDataset, dataset.ID }
+func (item *FolderItem) LinkRef() (string, int) { return item.ContentType, item.ObjectID }
+func (obj *TypedObject) LinkRef() (string, int) { return obj.ContentType, obj.ObjectID }
+
+// objectRef is the json representation of a Linkable in requests
+type objectRef struct {
+	ContentType string `json:"content_type"`
+	ObjectID    int    `json:"object_id"`
+}
+
+func newObjectRef(object Linkable) objectRef {
+	contentType, objectID := object.LinkRef()
+	return objectRef{ContentType: contentType, ObjectID: objectID}
+}
+
+func decodeContentObject(contentType string, data json.RawMessage) (ContentObject, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var target ContentObject
+	switch contentType {
+	case ContentTypeProject:
+		target = &Project{}
+	case ContentTypeFolder:
+		target = &Folder{}
+	case ContentTypePatient:
+		target = &Patient{}
+	case ContentTypeExam:
+		target = &Study{}
+	case ContentTypeSeries:
+		target = &Series{}
+	case ContentTypeDataset:
+		target = &Dataset{}
+	default:
+		var generic map[string]interface{}
+		err := json.Unmarshal(data, &generic)
+		return generic, err
+	}
+	err := json.Unmarshal(data, target)
+	return target, err
+}
+
+// unmarshalContent decodes data into alias, a type with the fields of the object but without its UnmarshalJSON method.
+// The content object is then decoded into the model of the content type
+func unmarshalContent(data []byte, alias interface{}, contentType *string, contentObject *ContentObject) error {
+	// json decodes into the value a non-nil pointer in an interface points to, the raw content object is kept that way
+	var raw json.RawMessage
+	*contentObject = &raw
+	if err := json.Unmarshal(data, alias); err != nil {
+		*contentObject = nil
+		return err
+	}
+	object, err := decodeContentObject(*contentType, raw)
+	if err != nil {
+		*contentObject = nil
+		return err
+	}
+	*contentObject = object
+	return nil
+}
+
+// setContentClient wires the content object if it is a model
+func setContentClient(contentObject ContentObject, client *http.Client) {
+	if model, ok := contentObject.(interface{ SetClient(*http.Client) }); ok {
+		model.SetClient(client)
+	}
+}
+
+func (obj *TypedObject) UnmarshalJSON(data []byte) error {
+	type typedObjectAlias TypedObject
+	return unmarshalContent(data, (*typedObjectAlias)(obj), &obj.ContentType, &obj.ContentObject)
+}
+
+func (obj *TypedObject) SetClient(client *http.Client) {
+	obj.Client = client
+	setContentClient(obj.ContentObject, client)
+}
+
+func (obj *TypedObject) AsProject() (*Project, bool) {
+	project, ok := obj.ContentObject.(*Project)
+	return project, ok
+}
+
+func (obj *TypedObject) AsFolder() (*Folder, bool) {
+	folder, ok := obj.ContentObject.(*Folder)
+	return folder, ok
+}
+
+func (obj *TypedObject) AsPatient() (*Patient, bool) {
+	patient, ok := obj.ContentObject.(*Patient)
+	return patient, ok
+}
+
+func (obj *TypedObject) AsStudy() (*Study, bool) {
+	study, ok := obj.ContentObject.(*Study)
+	return study, ok
+}
+
+func (obj *TypedObject) AsSeries() (*Series, bool) {
+	series, ok := obj.ContentObject.(*Series)
+	return series, ok
+}
+
+func (obj *TypedObject) AsDataset() (*Dataset, bool) {
+	dataset, ok := obj.ContentObject.(*Dataset)
+	return dataset, ok
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const DatafileURL = "api/v2/datafile/"
+
+type Datafile struct {
+	ID      int `json:"id"`
+	Dataset int `json:"dataset"`
+	// path relative to the dataset
+	Path         string    `json:"path"`
+	OriginalPath string    `json:"original_path"`
+	Size         int64     `json:"size"`
+	Sha1         string    `json:"sha1"`
+	CreatedDate  time.Time `json:"created_date"`
+	// only set in the import result: true if the file was newly created by the import
+	Created bool `json:"created"`
+
+	http.BaseModel
+}
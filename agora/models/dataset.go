@@ -0,0 +1,59 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const DatasetURL = "api/v2/dataset/"
+
+type DatasetType int
+
+const (
+	DatasetTypeNone            DatasetType = 0
+	DatasetTypeOther           DatasetType = 1
+	DatasetTypePhilipsRaw      DatasetType = 100
+	DatasetTypePhilipsParRec   DatasetType = 101
+	DatasetTypePhilipsSpectro  DatasetType = 102
+	DatasetTypePhilipsExamcard DatasetType = 103
+	DatasetTypePhilipsSinList  DatasetType = 104
+	DatasetTypeBrukerSubject   DatasetType = 200
+	DatasetTypeBrukerRaw       DatasetType = 201
+	DatasetTypeBrukerImage     DatasetType = 202
+	DatasetTypeDicom           DatasetType = 300
+	DatasetTypeSiemensRaw      DatasetType = 400
+	DatasetTypeIsmrmrd         DatasetType = 500
+	DatasetTypeNifti1          DatasetType = 600
+	DatasetTypeNifti2          DatasetType = 601
+	DatasetTypeNiftiAnalyze    DatasetType = 602
+)
+
+type Dataset struct {
+	ID           int         `json:"id"`
+	Name         string      `json:"name"`
+	Type         DatasetType `json:"type"`
+	Description  *string     `json:"description"`
+	Series       *int        `json:"series"`
+	Study        *int        `json:"exam"`
+	Size         int64       `json:"size"`
+	CreatedDate  time.Time   `json:"created_date"`
+	ModifiedDate time.Time   `json:"modified_date"`
+
+	http.BaseModel
+}
+
+func (dataset *Dataset) GetDatafiles() ([]Datafile, error) {
+	return dataset.GetDatafilesContext(context.Background())
+}
+
+func (dataset *Dataset) GetDatafilesContext(ctx context.Context) ([]Datafile, error) {
+	return dataset.IterateDatafiles(ctx, 0).All()
+}
+
+func (dataset *Dataset) IterateDatafiles(ctx context.Context, pageSize int) *http.Paginator[Datafile] {
+	url := fmt.Sprintf("%s%d/datafiles/", DatasetURL, dataset.ID)
+	return http.NewPaginator[Datafile](ctx, dataset.Client, url, pageSize)
+}
@@ -0,0 +1,530 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	agoraHttp "github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const (
+	TypeDownloadStarted   ProgressType = "download_started"   // data: DownloadProgressData
+	TypeDownloadProgress  ProgressType = "download_progress"  // data: DownloadProgressData
+	TypeDownloadCompleted ProgressType = "download_completed" // data: DownloadProgressData
+	TypeDownloadSkipped   ProgressType = "download_skipped"   // data: DownloadProgressData (the file exists already)
+	TypeDownloadError     ProgressType = "download_error"     // data: DownloadProgressData
+
+	// the suffix of partially downloaded files. They are resumed on the next download
+	PartialDownloadSuffix = ".agora_part"
+
+	downloadProgressInterval = 1024 * 1024
+)
+
+var (
+	ErrChecksumMismatch  = errors.New("the sha1 of the downloaded file does not match")
+	ErrDownloadCollision = errors.New("several datafiles have the same download destination")
+	ErrNoClient          = errors.New("the datafile has no client, it was not loaded from Agora")
+)
+
+type DownloadProgressData struct {
+	Datafile        Datafile
+	Path            string
+	TotalSize       int64
+	BytesTransfered int64
+	BytesIncrement  int64
+	Err             error
+}
+
+func (datafile *Datafile) Download(dest string, progressChan chan UploadProgress) error {
+	return datafile.DownloadContext(context.Background(), dest, progressChan)
+}
+
+// DownloadContext streams the datafile to dest. An interrupted download is resumed with a range request and the result
+// is verified against the sha1 of the server. If dest already exists with the same size and sha1 the download is skipped.
+func (datafile *Datafile) DownloadContext(ctx context.Context, dest string, progressChan chan UploadProgress) error {
+	_, err := datafile.downloadTo(ctx, dest, progressChan)
+	return err
+}
+
+func (datafile *Datafile) downloadTo(ctx context.Context, dest string, progressChan chan UploadProgress) (bool, error) {
+	if datafile.Client == nil {
+		return false, ErrNoClient
+	}
+	progress := DownloadProgressData{Datafile: *datafile, Path: dest, TotalSize: datafile.Size}
+	sendProgress(progressChan, TypeDownloadStarted, progress)
+
+	if fileMatches(dest, datafile.Size, datafile.Sha1) {
+		progress.BytesTransfered = progress.TotalSize
+		sendProgress(progressChan, TypeDownloadSkipped, progress)
+		return true, nil
+	}
+
+	err := datafile.download(ctx, dest, &progress, progressChan)
+	if err != nil {
+		progress.Err = err
+		sendProgress(progressChan, TypeDownloadError, progress)
+		return false, err
+	}
+	sendProgress(progressChan, TypeDownloadCompleted, progress)
+	return false, nil
+}
+
+func (datafile *Datafile) download(ctx context.Context, dest string, progress *DownloadProgressData, progressChan chan UploadProgress) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	partPath := dest + PartialDownloadSuffix
+
+	// connection errors in the middle of the body are resumed from the current offset. The request itself is already
+	// retried by the client
+	policy := datafile.Client.GetRetryPolicy()
+	for attempt := 1; ; attempt++ {
+		err := datafile.downloadPart(ctx, partPath, progress, progressChan)
+		if err == nil {
+			break
+		}
+		var readError *bodyReadError
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || !errors.As(err, &readError) {
+			return err
+		}
+
+		timer := time.NewTimer(policy.Backoff(attempt, nil))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if datafile.Sha1 != "" {
+		hash, err := sha1Hash(partPath)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(hash, datafile.Sha1) {
+			// the partial file is corrupt and must not be resumed
+			os.Remove(partPath)
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, dest)
+		}
+	}
+	return os.Rename(partPath, dest)
+}
+
+func (datafile *Datafile) downloadPart(ctx context.Context, partPath string, progress *DownloadProgressData, progressChan chan UploadProgress) error {
+	offset := int64(0)
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+	if datafile.Size > 0 && offset == datafile.Size {
+		progress.BytesTransfered = offset
+		return nil
+	} else if datafile.Size > 0 && offset > datafile.Size {
+		os.Remove(partPath)
+		offset = 0
+	}
+
+	url := datafile.Client.GetUrl(fmt.Sprintf("%s%d/download/", DatafileURL, datafile.ID))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := datafile.Client.SendRequest(req, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// the server ignored the range, start from the beginning
+		flags |= os.O_TRUNC
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is already complete (or corrupt, which is detected by the hash check)
+		progress.BytesTransfered = offset
+		return nil
+	default:
+		return agoraHttp.NewAPIError(resp)
+	}
+	if progress.TotalSize == 0 && resp.ContentLength > 0 {
+		progress.TotalSize = offset + resp.ContentLength
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	progress.BytesTransfered = offset
+	writer := &progressWriter{progress: progress, progressChan: progressChan}
+	body := &bodyReader{reader: resp.Body}
+	_, err = io.Copy(io.MultiWriter(file, writer), body)
+	writer.flush()
+	if err != nil && body.err != nil {
+		return &bodyReadError{err: err}
+	}
+	return err
+}
+
+// bodyReader remembers the errors of the response body. Only those can be resumed, a failed write (e.g. a full disk)
+// would fail again
+type bodyReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *bodyReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+type bodyReadError struct {
+	err error
+}
+
+func (e *bodyReadError) Error() string {
+	return e.err.Error()
+}
+
+func (e *bodyReadError) Unwrap() error {
+	return e.err
+}
+
+type progressWriter struct {
+	progress     *DownloadProgressData
+	progressChan chan UploadProgress
+	pending      int64
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.pending += int64(len(p))
+	if w.pending >= downloadProgressInterval {
+		w.flush()
+	}
+	return len(p), nil
+}
+
+func (w *progressWriter) flush() {
+	if w.pending == 0 {
+		return
+	}
+	w.progress.BytesTransfered += w.pending
+	w.progress.BytesIncrement = w.pending
+	w.pending = 0
+	sendProgress(w.progressChan, TypeDownloadProgress, *w.progress)
+}
+
+func (dataset *Dataset) Download(dir string, progressChan chan UploadProgress) error {
+	return dataset.DownloadContext(context.Background(), dir, progressChan)
+}
+
+// DownloadContext downloads all datafiles of the dataset into dir. The relative paths of the datafiles are kept
+func (dataset *Dataset) DownloadContext(ctx context.Context, dir string, progressChan chan UploadProgress) error {
+	var jobs []downloadJob
+	if err := dataset.collectDownloads(ctx, dir, &jobs); err != nil {
+		return err
+	}
+	return downloadJobs(ctx, jobs, progressChan)
+}
+
+func (series *Series) Download(dir string, progressChan chan UploadProgress) error {
+	return series.DownloadContext(context.Background(), dir, progressChan)
+}
+
+// DownloadContext downloads the datafiles of all datasets of the series into dir. Every dataset gets its own
+// sub-directory since datafiles of different datasets often have the same name
+func (series *Series) DownloadContext(ctx context.Context, dir string, progressChan chan UploadProgress) error {
+	var jobs []downloadJob
+	if err := series.collectDownloads(ctx, dir, &jobs); err != nil {
+		return err
+	}
+	return downloadJobs(ctx, jobs, progressChan)
+}
+
+// downloadJobs downloads the jobs one after the other. Nothing is downloaded if two jobs have the same destination
+func downloadJobs(ctx context.Context, jobs []downloadJob, progressChan chan UploadProgress) error {
+	if err := checkDestinations(jobs); err != nil {
+		return err
+	}
+	var errs []error
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := job.datafile.downloadTo(ctx, job.dest, progressChan); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errors.Join(errs...)
+}
+
+// relativePath returns a path relative to the dataset which cannot escape the download directory
+func (datafile *Datafile) relativePath() string {
+	path := datafile.Path
+	if path == "" {
+		path = filepath.Base(strings.ReplaceAll(datafile.OriginalPath, "\\", "/"))
+	}
+	var parts []string
+	for _, part := range strings.Split(strings.ReplaceAll(path, "\\", "/"), "/") {
+		part = sanitizeName(part)
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("datafile_%d", datafile.ID)
+	}
+	return filepath.Join(parts...)
+}
+
+// sanitizeName makes a name of an Agora object usable as a file or directory name
+func sanitizeName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "." || name == ".." {
+		return ""
+	}
+	replacer := strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_", "\x00", "")
+	return replacer.Replace(name)
+}
+
+func fileMatches(path string, size int64, sha1 string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if size > 0 && info.Size() != size {
+		return false
+	}
+	if sha1 == "" {
+		// without a hash we cannot tell if the file is identical
+		return false
+	}
+	hash, err := sha1Hash(path)
+	return err == nil && strings.EqualFold(hash, sha1)
+}
+
+func sendProgress(progressChan chan UploadProgress, progressType ProgressType, data DownloadProgressData) {
+	if progressChan != nil {
+		progressChan <- UploadProgress{Type: progressType, Data: data}
+	}
+}
+
+const PARALLEL_DOWNLOADS = 4
+
+type DownloadReport struct {
+	NrFiles         int
+	NrDownloaded    int
+	NrSkipped       int
+	NrFailed        int
+	BytesDownloaded int64
+	Downloaded      []string
+	Skipped         []string
+	Failed          []string
+	Errors          []error
+}
+
+type downloadJob struct {
+	datafile Datafile
+	dest     string
+}
+
+func (folder *Folder) DownloadTo(localDir string, workers int, progressChan chan UploadProgress) (*DownloadReport, error) {
+	return folder.DownloadToContext(context.Background(), localDir, workers, progressChan)
+}
+
+// DownloadToContext mirrors the folder with all sub-folders, studies, series and datasets into localDir. Files which
+// exist locally with the same size and sha1 are skipped. The report is also returned if some downloads failed, the
+// returned error then contains all failures. workers <= 0 uses PARALLEL_DOWNLOADS.
+func (folder *Folder) DownloadToContext(ctx context.Context, localDir string, workers int, progressChan chan UploadProgress) (*DownloadReport, error) {
+	var jobs []downloadJob
+	if err := folder.collectDownloads(ctx, localDir, &jobs); err != nil {
+		return nil, err
+	}
+	if err := checkDestinations(jobs); err != nil {
+		return nil, err
+	}
+	if workers <= 0 {
+		workers = PARALLEL_DOWNLOADS
+	}
+
+	report := &DownloadReport{NrFiles: len(jobs)}
+	var mutex sync.Mutex
+	jobCh := make(chan downloadJob)
+	wg := new(sync.WaitGroup)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				skipped, err := job.datafile.downloadTo(ctx, job.dest, progressChan)
+				mutex.Lock()
+				if err != nil {
+					report.NrFailed += 1
+					report.Failed = append(report.Failed, job.dest)
+					report.Errors = append(report.Errors, err)
+				} else if skipped {
+					report.NrSkipped += 1
+					report.Skipped = append(report.Skipped, job.dest)
+				} else {
+					report.NrDownloaded += 1
+					report.Downloaded = append(report.Downloaded, job.dest)
+					if info, err := os.Stat(job.dest); err == nil {
+						report.BytesDownloaded += info.Size()
+					}
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		jobCh <- job
+	}
+	close(jobCh)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return report, ctx.Err()
+	}
+	return report, errors.Join(report.Errors...)
+}
+
+func (folder *Folder) collectDownloads(ctx context.Context, dir string, jobs *[]downloadJob) error {
+	items, err := folder.GetItemsContext(ctx)
+	if err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for i := range items {
+		item := &items[i]
+		switch item.ContentType {
+		case ContentTypeFolder:
+			subFolder, ok := item.AsFolder()
+			if !ok {
+				continue
+			}
+			subDir := filepath.Join(dir, uniqueName(names, subFolder.Name, subFolder.ID))
+			if err := subFolder.collectDownloads(ctx, subDir, jobs); err != nil {
+				return err
+			}
+		case ContentTypeExam:
+			study, ok := item.AsStudy()
+			if !ok {
+				continue
+			}
+			studyDir := filepath.Join(dir, uniqueName(names, study.Name, study.ID))
+			if err := study.collectDownloads(ctx, studyDir, jobs); err != nil {
+				return err
+			}
+		case ContentTypeSeries:
+			series, ok := item.AsSeries()
+			if !ok {
+				continue
+			}
+			seriesDir := filepath.Join(dir, uniqueName(names, series.Name, series.ID))
+			if err := series.collectDownloads(ctx, seriesDir, jobs); err != nil {
+				return err
+			}
+		case ContentTypeDataset:
+			dataset, ok := item.AsDataset()
+			if !ok {
+				continue
+			}
+			datasetDir := filepath.Join(dir, uniqueName(names, dataset.Name, dataset.ID))
+			if err := dataset.collectDownloads(ctx, datasetDir, jobs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (study *Study) collectDownloads(ctx context.Context, dir string, jobs *[]downloadJob) error {
+	series, err := study.GetSeriesContext(ctx)
+	if err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for i := range series {
+		seriesDir := filepath.Join(dir, uniqueName(names, series[i].Name, series[i].ID))
+		if err := series[i].collectDownloads(ctx, seriesDir, jobs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (series *Series) collectDownloads(ctx context.Context, dir string, jobs *[]downloadJob) error {
+	datasets, err := series.GetDatasetsContext(ctx)
+	if err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for i := range datasets {
+		datasetDir := filepath.Join(dir, uniqueName(names, datasets[i].Name, datasets[i].ID))
+		if err := datasets[i].collectDownloads(ctx, datasetDir, jobs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dataset *Dataset) collectDownloads(ctx context.Context, dir string, jobs *[]downloadJob) error {
+	datafiles, err := dataset.GetDatafilesContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, datafile := range datafiles {
+		*jobs = append(*jobs, downloadJob{datafile: datafile, dest: filepath.Join(dir, datafile.relativePath())})
+	}
+	return nil
+}
+
+// checkDestinations fails if two datafiles would be downloaded to the same file. Paths are compared case-insensitive
+// since the download directory might be on a case-insensitive file system.
+func checkDestinations(jobs []downloadJob) error {
+	dests := make(map[string]int, len(jobs))
+	for _, job := range jobs {
+		key := strings.ToLower(filepath.Clean(job.dest))
+		if id, ok := dests[key]; ok {
+			return fmt.Errorf("%w: datafiles %d and %d: %s", ErrDownloadCollision, id, job.datafile.ID, job.dest)
+		}
+		dests[key] = job.datafile.ID
+	}
+	return nil
+}
+
+// uniqueName returns a sanitized directory name which is not yet used in the parent directory
+func uniqueName(names map[string]bool, name string, id int) string {
+	name = sanitizeName(name)
+	if name == "" || names[strings.ToLower(name)] {
+		name = fmt.Sprintf("%s_%d", name, id)
+	}
+	names[strings.ToLower(name)] = true
+	return name
+}
@@ -1,19 +1,21 @@
 package models
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/GyroTools/gtagora-connector-go/internals/http"
 )
 
 const FolderURL = "api/v2/folder/"
 const FolderItemURL = "api/v2/folderitem/"
-const (
-	ContentTypeFolder  = "folder"
-	ContentTypeExam    = "exam"
-	ContentTypeSeries  = "series"
-	ContentTypeDataset = "dataset"
+
+var (
+	// ErrFolderNotFound also matches http.ErrNotFound
+	ErrFolderNotFound = fmt.Errorf("folder %w", http.ErrNotFound)
+	ErrAmbiguousPath  = errors.New("ambiguous folder path")
 )
 
 type Folder struct {
@@ -36,8 +38,6 @@ type FolderItem struct {
 	http.BaseModel
 }
 
-type ContentObject interface{}
-
 type FolderContent struct {
 	ID      int    `json:"id"`
 	Name    string `json:"name"`
@@ -45,37 +45,370 @@ type FolderContent struct {
 }
 
 func (folder *Folder) GetItems() ([]FolderItem, error) {
+	return folder.GetItemsContext(context.Background())
+}
+
+func (folder *Folder) GetItemsContext(ctx context.Context) ([]FolderItem, error) {
+	return folder.IterateItems(ctx, 0).All()
+}
+
+// IterateItems returns an iterator which fetches the folder items page by page. A pageSize of 0 uses the default page size of the client
+func (folder *Folder) IterateItems(ctx context.Context, pageSize int) *http.Paginator[FolderItem] {
 	path := fmt.Sprintf("%s%d/items/", FolderURL, folder.ID)
-	path += "?limit=10000000000"
-	var folderItems []FolderItem
-	err := folder.Client.GetAndParse(path, &folderItems)
-	if err != nil {
-		return nil, err
-	}
-	return folderItems, nil
+	return http.NewPaginator[FolderItem](ctx, folder.Client, path, pageSize)
 }
 
 func (folder *Folder) GetFolders() ([]Folder, error) {
-	items, err := folder.GetItems()
+	return folder.GetFoldersContext(context.Background())
+}
+
+func (folder *Folder) GetFoldersContext(ctx context.Context) ([]Folder, error) {
+	items, err := folder.GetItemsContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	var folders []Folder
 	for _, item := range items {
 		if item.ContentType == ContentTypeFolder {
-			contentBytes, err := json.Marshal(item.ContentObject)
-			if err != nil {
-				return nil, err
-			}
-			var curFolder Folder
-			err = json.Unmarshal(contentBytes, &curFolder)
-			if err != nil {
-				return nil, err
+			if curFolder, ok := item.AsFolder(); ok {
+				folders = append(folders, *curFolder)
 			}
-			curFolder.URL = item.URL
-			curFolder.Client = item.Client
-			folders = append(folders, curFolder)
 		}
 	}
 	return folders, nil
 }
+
+func (folder *Folder) GetStudies() ([]Study, error) {
+	return folder.GetStudiesContext(context.Background())
+}
+
+func (folder *Folder) GetStudiesContext(ctx context.Context) ([]Study, error) {
+	items, err := folder.GetItemsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var studies []Study
+	for _, item := range items {
+		if study, ok := item.AsStudy(); ok {
+			studies = append(studies, *study)
+		}
+	}
+	return studies, nil
+}
+
+func (folder *Folder) GetSeries() ([]Series, error) {
+	return folder.GetSeriesContext(context.Background())
+}
+
+func (folder *Folder) GetSeriesContext(ctx context.Context) ([]Series, error) {
+	items, err := folder.GetItemsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var series []Series
+	for _, item := range items {
+		if curSeries, ok := item.AsSeries(); ok {
+			series = append(series, *curSeries)
+		}
+	}
+	return series, nil
+}
+
+func (folder *Folder) GetDatasets() ([]Dataset, error) {
+	return folder.GetDatasetsContext(context.Background())
+}
+
+func (folder *Folder) GetDatasetsContext(ctx context.Context) ([]Dataset, error) {
+	items, err := folder.GetItemsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var datasets []Dataset
+	for _, item := range items {
+		if dataset, ok := item.AsDataset(); ok {
+			datasets = append(datasets, *dataset)
+		}
+	}
+	return datasets, nil
+}
+
+func (folder *Folder) CreateFolder(name string) (*Folder, error) {
+	return folder.CreateFolderContext(context.Background(), name)
+}
+
+func (folder *Folder) CreateFolderContext(ctx context.Context, name string) (*Folder, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("the folder name must not be empty")
+	}
+	var newFolder Folder
+	path := fmt.Sprintf("%s%d/new/", FolderURL, folder.ID)
+	err := folder.Client.DoContext(ctx, "POST", path, map[string]string{"name": name}, &newFolder)
+	if err != nil {
+		return nil, err
+	}
+	return &newFolder, nil
+}
+
+func (folder *Folder) Rename(name string) error {
+	return folder.RenameContext(context.Background(), name)
+}
+
+func (folder *Folder) RenameContext(ctx context.Context, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("the folder name must not be empty")
+	}
+	path := fmt.Sprintf("%s%d/", FolderURL, folder.ID)
+	return folder.Client.DoContext(ctx, "PATCH", path, map[string]string{"name": name}, folder)
+}
+
+func (folder *Folder) Delete() error {
+	return folder.DeleteContext(context.Background())
+}
+
+func (folder *Folder) DeleteContext(ctx context.Context) error {
+	path := fmt.Sprintf("%s%d/", FolderURL, folder.ID)
+	return folder.Client.DoContext(ctx, "DELETE", path, nil, nil)
+}
+
+// GetOrCreatePath returns the folder at the "/" separated path relative to this folder. Missing folders are created,
+// existing ones are reused. Therefore it can be called repeatedly with the same path.
+func (folder *Folder) GetOrCreatePath(path string) (*Folder, error) {
+	return folder.GetOrCreatePathContext(context.Background(), path)
+}
+
+func (folder *Folder) GetOrCreatePathContext(ctx context.Context, path string) (*Folder, error) {
+	current := folder
+	for _, name := range splitFolderPath(path) {
+		next, err := current.subFolder(ctx, name)
+		if errors.Is(err, ErrFolderNotFound) {
+			next, err = current.CreateFolderContext(ctx, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// Resolve returns the folder at the "/" separated path relative to this folder. It fails with ErrFolderNotFound if a
+// folder does not exist and with ErrAmbiguousPath if a level contains several folders with the same name.
+func (folder *Folder) Resolve(relPath string) (*Folder, error) {
+	return folder.ResolveContext(context.Background(), relPath)
+}
+
+func (folder *Folder) ResolveContext(ctx context.Context, relPath string) (*Folder, error) {
+	current := folder
+	for _, name := range splitFolderPath(relPath) {
+		next, err := current.subFolder(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// Path returns the breadcrumb of the folder: all folders from the root folder of the project down to this folder
+func (folder *Folder) Path() ([]Folder, error) {
+	return folder.PathContext(context.Background())
+}
+
+func (folder *Folder) PathContext(ctx context.Context) ([]Folder, error) {
+	var breadcrumb []Folder
+	path := fmt.Sprintf("%s%d/breadcrumb/", FolderURL, folder.ID)
+	err := folder.Client.GetAndParseContext(ctx, path, &breadcrumb)
+	if err != nil {
+		return nil, err
+	}
+	return breadcrumb, nil
+}
+
+// subFolder returns the sub-folder with the given name. Names are compared case-sensitive like in Agora
+func (folder *Folder) subFolder(ctx context.Context, name string) (*Folder, error) {
+	subFolders, err := folder.GetFoldersContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*Folder
+	for i := range subFolders {
+		if subFolders[i].Name == name {
+			matches = append(matches, &subFolders[i])
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: \"%s\" in folder \"%s\" (id=%d)", ErrFolderNotFound, name, folder.Name, folder.ID)
+	} else if len(matches) > 1 {
+		ids := make([]string, len(matches))
+		for i, match := range matches {
+			ids[i] = fmt.Sprintf("%d", match.ID)
+		}
+		return nil, fmt.Errorf("%w: folder \"%s\" (id=%d) contains %d folders named \"%s\" (ids=%s)", ErrAmbiguousPath, folder.Name, folder.ID, len(matches), name, strings.Join(ids, ", "))
+	}
+	return matches[0], nil
+}
+
+func splitFolderPath(path string) []string {
+	var names []string
+	for _, name := range strings.Split(path, "/") {
+		name = strings.TrimSpace(name)
+		if name != "" && name != "." {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (item *FolderItem) UnmarshalJSON(data []byte) error {
+	type folderItemAlias FolderItem
+	return unmarshalContent(data, (*folderItemAlias)(item), &item.ContentType, &item.ContentObject)
+}
+
+func (item *FolderItem) SetClient(client *http.Client) {
+	item.Client = client
+	setContentClient(item.ContentObject, client)
+}
+
+func (item *FolderItem) AsFolder() (*Folder, bool) {
+	folder, ok := item.ContentObject.(*Folder)
+	return folder, ok
+}
+
+func (item *FolderItem) AsStudy() (*Study, bool) {
+	study, ok := item.ContentObject.(*Study)
+	return study, ok
+}
+
+func (item *FolderItem) AsSeries() (*Series, bool) {
+	series, ok := item.ContentObject.(*Series)
+	return series, ok
+}
+
+func (item *FolderItem) AsDataset() (*Dataset, bool) {
+	dataset, ok := item.ContentObject.(*Dataset)
+	return dataset, ok
+}
+
+// MoveTo moves the item into target. The item is updated with the new folder
+func (item *FolderItem) MoveTo(target *Folder) error {
+	return item.MoveToContext(context.Background(), target)
+}
+
+func (item *FolderItem) MoveToContext(ctx context.Context, target *Folder) error {
+	items := []FolderItem{*item}
+	if err := target.MoveItemsContext(ctx, items); err != nil {
+		return err
+	}
+	item.Folder = items[0].Folder
+	return nil
+}
+
+// LinkTo creates a link to the content of the item in target. The item itself stays in its folder
+func (item *FolderItem) LinkTo(target *Folder) (*FolderItem, error) {
+	return item.LinkToContext(context.Background(), target)
+}
+
+func (item *FolderItem) LinkToContext(ctx context.Context, target *Folder) (*FolderItem, error) {
+	return target.AddLinkContext(ctx, item)
+}
+
+// CopyTo copies the content of the item into target and returns the folder item of the copy
+func (item *FolderItem) CopyTo(target *Folder) (*FolderItem, error) {
+	return item.CopyToContext(context.Background(), target)
+}
+
+func (item *FolderItem) CopyToContext(ctx context.Context, target *Folder) (*FolderItem, error) {
+	items, err := target.CopyItemsContext(ctx, []FolderItem{*item})
+	if err != nil {
+		return nil, err
+	}
+	if len(items) != 1 {
+		return nil, fmt.Errorf("expected 1 folder item but the server returned %d", len(items))
+	}
+	return &items[0], nil
+}
+
+// CopyItems copies the content of several folder items into this folder with a single request and returns the new
+// folder items
+func (folder *Folder) CopyItems(items []FolderItem) ([]FolderItem, error) {
+	return folder.CopyItemsContext(context.Background(), items)
+}
+
+func (folder *Folder) CopyItemsContext(ctx context.Context, items []FolderItem) ([]FolderItem, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	var copies []FolderItem
+	path := fmt.Sprintf("%s%d/copy/", FolderURL, folder.ID)
+	err := folder.Client.DoContext(ctx, "POST", path, map[string][]int{"items": ids}, &copies)
+	if err != nil {
+		return nil, err
+	}
+	return copies, nil
+}
+
+// MoveItems moves several folder items into this folder with a single request. The Folder of the items is updated
+func (folder *Folder) MoveItems(items []FolderItem) error {
+	return folder.MoveItemsContext(context.Background(), items)
+}
+
+func (folder *Folder) MoveItemsContext(ctx context.Context, items []FolderItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]int, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	path := fmt.Sprintf("%s%d/move/", FolderURL, folder.ID)
+	err := folder.Client.DoContext(ctx, "POST", path, map[string][]int{"items": ids}, nil)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Folder = folder.ID
+	}
+	return nil
+}
+
+// AddLink links a folder, study, series, dataset or the content of a folder item into this folder
+func (folder *Folder) AddLink(object Linkable) (*FolderItem, error) {
+	return folder.AddLinkContext(context.Background(), object)
+}
+
+func (folder *Folder) AddLinkContext(ctx context.Context, object Linkable) (*FolderItem, error) {
+	items, err := folder.AddLinksContext(ctx, []Linkable{object})
+	if err != nil {
+		return nil, err
+	}
+	if len(items) != 1 {
+		return nil, fmt.Errorf("expected 1 folder item but the server returned %d", len(items))
+	}
+	return &items[0], nil
+}
+
+// AddLinks links several objects into this folder with a single request and returns the new folder items
+func (folder *Folder) AddLinks(objects []Linkable) ([]FolderItem, error) {
+	return folder.AddLinksContext(context.Background(), objects)
+}
+
+func (folder *Folder) AddLinksContext(ctx context.Context, objects []Linkable) ([]FolderItem, error) {
+	if len(objects) == 0 {
+		return nil, nil
+	}
+	links := make([]objectRef, len(objects))
+	for i, object := range objects {
+		links[i] = newObjectRef(object)
+	}
+	var items []FolderItem
+	path := fmt.Sprintf("%s%d/link/", FolderURL, folder.ID)
+	err := folder.Client.DoContext(ctx, "POST", path, map[string][]objectRef{"items": links}, &items)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const HostURL = "api/v2/host/"
+
+// Host is a registered machine which executes tasks
+type Host struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Online       bool       `json:"is_online"`
+	LastSeen     *time.Time `json:"last_seen"`
+	Capabilities []string   `json:"capabilities"`
+	Version      string     `json:"version"`
+
+	http.BaseModel
+}
+
+func (host *Host) HasCapability(capability string) bool {
+	for _, cur := range host.Capabilities {
+		if cur == capability {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const ParameterQueryURL = "api/v2/parameter/query/"
+
+var ErrInvalidParameterFilter = errors.New("invalid parameter filter")
+
+// Parameter is a scanner parameter extracted by Agora, e.g. a DICOM tag or a Philips raw header value
+type Parameter struct {
+	Name        string      `json:"name"`
+	Value       interface{} `json:"value"`
+	Unit        string      `json:"unit"`
+	Description string      `json:"description"`
+}
+
+// Float returns the value as number. Numeric strings are converted as well
+func (parameter *Parameter) Float() (float64, bool) {
+	switch v := parameter.Value.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// String returns the value formatted as text
+func (parameter *Parameter) String() string {
+	if s, ok := parameter.Value.(string); ok {
+		return s
+	}
+	if parameter.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(parameter.Value)
+}
+
+// ParameterSet contains all parameters of a dataset
+type ParameterSet struct {
+	Parameters []Parameter
+}
+
+// Get returns the parameter with the given name. Names are compared case-insensitive
+func (set *ParameterSet) Get(name string) (*Parameter, bool) {
+	for i := range set.Parameters {
+		if strings.EqualFold(set.Parameters[i].Name, name) {
+			return &set.Parameters[i], true
+		}
+	}
+	return nil, false
+}
+
+func (set *ParameterSet) Names() []string {
+	names := make([]string, len(set.Parameters))
+	for i := range set.Parameters {
+		names[i] = set.Parameters[i].Name
+	}
+	return names
+}
+
+func (dataset *Dataset) GetParameters() (*ParameterSet, error) {
+	return dataset.GetParametersContext(context.Background())
+}
+
+func (dataset *Dataset) GetParametersContext(ctx context.Context) (*ParameterSet, error) {
+	path := fmt.Sprintf("%s%d/parameters/", DatasetURL, dataset.ID)
+	parameters, err := http.NewPaginator[Parameter](ctx, dataset.Client, path, 0).All()
+	if err != nil {
+		return nil, err
+	}
+	return &ParameterSet{Parameters: parameters}, nil
+}
+
+// ParameterCondition is a single comparison of a parameter filter, e.g. "EchoTime < 5"
+type ParameterCondition struct {
+	Name     string      `json:"name"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value"`
+}
+
+var filterConditionRegexp = regexp.MustCompile(`^([A-Za-z_][\w.\-]*)\s*(<=|>=|!=|==|=|<|>)\s*(.+)$`)
+
+// ParseParameterFilter parses a filter like "EchoTime < 5 and ScanMode = 3D". Conditions are combined with "and". The
+// operators are =, !=, <, <=, > and >=. Numeric values are sent as numbers, everything else as text. Values can be quoted.
+func ParseParameterFilter(filter string) ([]ParameterCondition, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, fmt.Errorf("%w: the filter is empty", ErrInvalidParameterFilter)
+	}
+	var conditions []ParameterCondition
+	for _, part := range splitFilter(filter) {
+		match := filterConditionRegexp.FindStringSubmatch(strings.TrimSpace(part))
+		if match == nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidParameterFilter, part)
+		}
+		operator := match[2]
+		if operator == "==" {
+			operator = "="
+		}
+		conditions = append(conditions, ParameterCondition{Name: match[1], Operator: operator, Value: filterValue(match[3])})
+	}
+	return conditions, nil
+}
+
+// splitFilter splits the filter at "and" (case-insensitive) but not inside quoted values. The parts are not modified
+// otherwise, whitespace in quoted values is kept
+func splitFilter(filter string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(filter); i++ {
+		c := filter[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+			continue
+		}
+		end := i + 4
+		if isSpace(c) && end <= len(filter) && strings.EqualFold(filter[i+1:end], "and") && (end == len(filter) || isSpace(filter[end])) &&
+			strings.TrimSpace(filter[start:i]) != "" {
+			parts = append(parts, strings.TrimSpace(filter[start:i]))
+			start = end
+			i = end - 1
+		}
+	}
+	return append(parts, strings.TrimSpace(filter[start:]))
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func filterValue(value string) interface{} {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+// QueryByParameter returns all datasets whose parameters match the filter. See ParseParameterFilter for the syntax
+func QueryByParameter(ctx context.Context, client *http.Client, filter string) ([]Dataset, error) {
+	conditions, err := ParseParameterFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string][]ParameterCondition{"conditions": conditions}
+	paginator, err := http.NewPostPaginator[Dataset](ctx, client, ParameterQueryURL, body, 0)
+	if err != nil {
+		return nil, err
+	}
+	return paginator.All()
+}
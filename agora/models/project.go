@@ -1,7 +1,10 @@
 package models
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/GyroTools/gtagora-connector-go/internals/http"
@@ -26,30 +29,191 @@ type Project struct {
 }
 
 type Membership struct {
-	User    int `json:"user"`
-	Role    int `json:"role"`
-	ID      int `json:"id"`
-	Project int `json:"project"`
+	User    int  `json:"user"`
+	Role    Role `json:"role"`
+	ID      int  `json:"id"`
+	Project int  `json:"project"`
+
+	http.BaseModel
+}
+
+// Role is the role of a user in a project
+type Role int
+
+const (
+	RoleAdmin  Role = 1
+	RoleMember Role = 2
+	RoleGuest  Role = 3
+)
+
+func (role Role) String() string {
+	switch role {
+	case RoleAdmin:
+		return "admin"
+	case RoleMember:
+		return "member"
+	case RoleGuest:
+		return "guest"
+	}
+	return fmt.Sprintf("Role(%d)", int(role))
+}
+
+var ErrMemberNotFound = fmt.Errorf("member %w", http.ErrNotFound)
+
+type projectUpdate struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+type membershipRequest struct {
+	User int  `json:"user,omitempty"`
+	Role Role `json:"role"`
+}
+
+func (project *Project) SetClient(client *http.Client) {
+	project.Client = client
+	for i := range project.Memberships {
+		project.Memberships[i].Client = client
+	}
+}
+
+// GetUser returns the user of the membership
+func (membership *Membership) GetUser() (*User, error) {
+	return membership.GetUserContext(context.Background())
+}
+
+func (membership *Membership) GetUserContext(ctx context.Context) (*User, error) {
+	return getUser(ctx, membership.Client, membership.User)
+}
+
+// GetOwner returns the owner of the project. It returns nil if the project has no owner
+func (project *Project) GetOwner() (*User, error) {
+	return project.GetOwnerContext(context.Background())
+}
+
+func (project *Project) GetOwnerContext(ctx context.Context) (*User, error) {
+	if project.Owner == nil {
+		return nil, nil
+	}
+	return getUser(ctx, project.Client, *project.Owner)
 }
 
 func (project *Project) GetStudies() ([]Study, error) {
-	var studies []Study
+	return project.GetStudiesContext(context.Background())
+}
+
+func (project *Project) GetStudiesContext(ctx context.Context) ([]Study, error) {
+	return project.IterateStudies(ctx, 0).All()
+}
+
+// IterateStudies returns an iterator which fetches the studies page by page. A pageSize of 0 uses the default page size of the client
+func (project *Project) IterateStudies(ctx context.Context, pageSize int) *http.Paginator[Study] {
+	url := fmt.Sprintf("%s%d/exam/", ProjectURL, project.ID)
+	return http.NewPaginator[Study](ctx, project.Client, url, pageSize)
+}
+
+func (project *Project) GetPatients() ([]Patient, error) {
+	return project.GetPatientsContext(context.Background())
+}
+
+func (project *Project) GetPatientsContext(ctx context.Context) ([]Patient, error) {
+	return project.IteratePatients(ctx, 0).All()
+}
+
+func (project *Project) IteratePatients(ctx context.Context, pageSize int) *http.Paginator[Patient] {
+	url := fmt.Sprintf("%s%d/patient/", ProjectURL, project.ID)
+	return http.NewPaginator[Patient](ctx, project.Client, url, pageSize)
+}
+
+// GetFolderByPath returns the folder at the "/" separated path below the root folder of the project
+func (project *Project) GetFolderByPath(path string) (*Folder, error) {
+	return project.GetFolderByPathContext(context.Background(), path)
+}
 
-	url := fmt.Sprintf("%sexam/?limit=10000000000", project.URL)
-	err := project.Client.GetAndParse(url, &studies)
+func (project *Project) GetFolderByPathContext(ctx context.Context, path string) (*Folder, error) {
+	var rootFolder Folder
+	err := project.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", FolderURL, project.RootFolder), &rootFolder)
 	if err != nil {
 		return nil, err
 	}
-	return studies, nil
+	return rootFolder.ResolveContext(ctx, path)
 }
 
-func (project *Project) GetPatients() ([]Patient, error) {
-	var patients []Patient
+// Update saves the name and the description of the project
+func (project *Project) Update() error {
+	return project.UpdateContext(context.Background())
+}
+
+func (project *Project) UpdateContext(ctx context.Context) error {
+	if strings.TrimSpace(project.Name) == "" {
+		return errors.New("the project name must not be empty")
+	}
+	path := fmt.Sprintf("%s%d/", ProjectURL, project.ID)
+	data := projectUpdate{Name: project.Name, Description: project.Description}
+	return project.Client.DoContext(ctx, "PATCH", path, data, project)
+}
+
+// GetMembership returns the membership of the user with the given id
+func (project *Project) GetMembership(userID int) (*Membership, error) {
+	for i := range project.Memberships {
+		if project.Memberships[i].User == userID {
+			return &project.Memberships[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: user %d in project %d", ErrMemberNotFound, userID, project.ID)
+}
+
+// AddMember adds the user with the given id to the project. Memberships is updated with the new membership
+func (project *Project) AddMember(userID int, role Role) (*Membership, error) {
+	return project.AddMemberContext(context.Background(), userID, role)
+}
 
-	url := fmt.Sprintf("%spatient/?limit=10000000000", project.URL)
-	err := project.Client.GetAndParse(url, &patients)
+func (project *Project) AddMemberContext(ctx context.Context, userID int, role Role) (*Membership, error) {
+	var membership Membership
+	path := fmt.Sprintf("%s%d/members/", ProjectURL, project.ID)
+	err := project.Client.DoContext(ctx, "POST", path, membershipRequest{User: userID, Role: role}, &membership)
 	if err != nil {
 		return nil, err
 	}
-	return patients, nil
+	membership.Client = project.Client
+	project.Memberships = append(project.Memberships, membership)
+	return &project.Memberships[len(project.Memberships)-1], nil
+}
+
+// RemoveMember removes the user with the given id from the project
+func (project *Project) RemoveMember(userID int) error {
+	return project.RemoveMemberContext(context.Background(), userID)
+}
+
+func (project *Project) RemoveMemberContext(ctx context.Context, userID int) error {
+	membership, err := project.GetMembership(userID)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("%s%d/members/%d/", ProjectURL, project.ID, membership.ID)
+	err = project.Client.DoContext(ctx, "DELETE", path, nil, nil)
+	if err != nil {
+		return err
+	}
+	for i := range project.Memberships {
+		if project.Memberships[i].ID == membership.ID {
+			project.Memberships = append(project.Memberships[:i], project.Memberships[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// SetRole changes the role of a member of the project
+func (project *Project) SetRole(userID int, role Role) error {
+	return project.SetRoleContext(context.Background(), userID, role)
+}
+
+func (project *Project) SetRoleContext(ctx context.Context, userID int, role Role) error {
+	membership, err := project.GetMembership(userID)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("%s%d/members/%d/", ProjectURL, project.ID, membership.ID)
+	return project.Client.DoContext(ctx, "PATCH", path, membershipRequest{Role: role}, membership)
 }
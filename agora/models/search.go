@@ -0,0 +1,52 @@
+package models
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const SearchURL = "api/v2/search/"
+
+// SearchOptions restricts the results of a search. Zero values are not used as filter
+type SearchOptions struct {
+	// ContentTypes are models.ContentType constants, e.g. ContentTypeExam
+	ContentTypes  []string
+	Project       int
+	Owner         int
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+func (opts *SearchOptions) query(query string) url.Values {
+	values := url.Values{}
+	values.Set("q", query)
+	if opts == nil {
+		return values
+	}
+	for _, contentType := range opts.ContentTypes {
+		values.Add("content_type", contentType)
+	}
+	if opts.Project != 0 {
+		values.Set("project", strconv.Itoa(opts.Project))
+	}
+	if opts.Owner != 0 {
+		values.Set("owner", strconv.Itoa(opts.Owner))
+	}
+	if !opts.CreatedAfter.IsZero() {
+		values.Set("created_after", opts.CreatedAfter.Format(time.RFC3339))
+	}
+	if !opts.CreatedBefore.IsZero() {
+		values.Set("created_before", opts.CreatedBefore.Format(time.RFC3339))
+	}
+	return values
+}
+
+// NewSearch returns an iterator over the results of a search. opts can be nil. A pageSize of 0 uses the default page size of the client
+func NewSearch(ctx context.Context, client *http.Client, query string, opts *SearchOptions, pageSize int) *http.Paginator[TypedObject] {
+	path := SearchURL + "?" + opts.query(query).Encode()
+	return http.NewPaginator[TypedObject](ctx, client, path, pageSize)
+}
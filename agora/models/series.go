@@ -0,0 +1,39 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const SeriesURL = "api/v2/series/"
+
+type Series struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Number       *int      `json:"series_number"`
+	Modality     *string   `json:"modality"`
+	Protocol     *string   `json:"protocol_name"`
+	Description  *string   `json:"description"`
+	Study        *int      `json:"exam"`
+	StartTime    time.Time `json:"acquisition_date_time"`
+	CreatedDate  time.Time `json:"created_date"`
+	ModifiedDate time.Time `json:"modified_date"`
+
+	http.BaseModel
+}
+
+func (series *Series) GetDatasets() ([]Dataset, error) {
+	return series.GetDatasetsContext(context.Background())
+}
+
+func (series *Series) GetDatasetsContext(ctx context.Context) ([]Dataset, error) {
+	return series.IterateDatasets(ctx, 0).All()
+}
+
+func (series *Series) IterateDatasets(ctx context.Context, pageSize int) *http.Paginator[Dataset] {
+	url := fmt.Sprintf("%s%d/datasets/", SeriesURL, series.ID)
+	return http.NewPaginator[Dataset](ctx, series.Client, url, pageSize)
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/GyroTools/gtagora-connector-go/internals/http"
@@ -22,3 +24,29 @@ type Study struct {
 
 	http.BaseModel
 }
+
+func (study *Study) GetSeries() ([]Series, error) {
+	return study.GetSeriesContext(context.Background())
+}
+
+func (study *Study) GetSeriesContext(ctx context.Context) ([]Series, error) {
+	return study.IterateSeries(ctx, 0).All()
+}
+
+func (study *Study) IterateSeries(ctx context.Context, pageSize int) *http.Paginator[Series] {
+	url := fmt.Sprintf("%s%d/series/", StudyURL, study.ID)
+	return http.NewPaginator[Series](ctx, study.Client, url, pageSize)
+}
+
+func (study *Study) GetDatasets() ([]Dataset, error) {
+	return study.GetDatasetsContext(context.Background())
+}
+
+func (study *Study) GetDatasetsContext(ctx context.Context) ([]Dataset, error) {
+	return study.IterateDatasets(ctx, 0).All()
+}
+
+func (study *Study) IterateDatasets(ctx context.Context, pageSize int) *http.Paginator[Dataset] {
+	url := fmt.Sprintf("%s%d/datasets/", StudyURL, study.ID)
+	return http.NewPaginator[Dataset](ctx, study.Client, url, pageSize)
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const TagURL = "api/v2/tag/"
+
+type Tag struct {
+	ID          int       `json:"id"`
+	Label       string    `json:"label"`
+	Color       string    `json:"color"`
+	CreatedDate time.Time `json:"created_date"`
+
+	http.BaseModel
+}
+
+// GetObjects returns all objects with this tag. An empty contentType returns objects of all types
+func (tag *Tag) GetObjects(contentType string) ([]TypedObject, error) {
+	return tag.GetObjectsContext(context.Background(), contentType)
+}
+
+func (tag *Tag) GetObjectsContext(ctx context.Context, contentType string) ([]TypedObject, error) {
+	return tag.IterateObjects(ctx, contentType, 0).All()
+}
+
+func (tag *Tag) IterateObjects(ctx context.Context, contentType string, pageSize int) *http.Paginator[TypedObject] {
+	path := fmt.Sprintf("%s%d/objects/", TagURL, tag.ID)
+	if contentType != "" {
+		path += "?content_type=" + url.QueryEscape(contentType)
+	}
+	return http.NewPaginator[TypedObject](ctx, tag.Client, path, pageSize)
+}
+
+func assignTag(ctx context.Context, client *http.Client, tag *Tag, object Linkable, assign bool) error {
+	action := "assign"
+	if !assign {
+		action = "unassign"
+	}
+	path := fmt.Sprintf("%s%d/%s/", TagURL, tag.ID, action)
+	return client.DoContext(ctx, "POST", path, newObjectRef(object), nil)
+}
+
+func (project *Project) Tag(tag *Tag) error {
+	return project.TagContext(context.Background(), tag)
+}
+
+func (project *Project) TagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, project.Client, tag, project, true)
+}
+
+func (project *Project) Untag(tag *Tag) error {
+	return project.UntagContext(context.Background(), tag)
+}
+
+func (project *Project) UntagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, project.Client, tag, project, false)
+}
+
+func (folder *Folder) Tag(tag *Tag) error {
+	return folder.TagContext(context.Background(), tag)
+}
+
+func (folder *Folder) TagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, folder.Client, tag, folder, true)
+}
+
+func (folder *Folder) Untag(tag *Tag) error {
+	return folder.UntagContext(context.Background(), tag)
+}
+
+func (folder *Folder) UntagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, folder.Client, tag, folder, false)
+}
+
+func (patient *Patient) Tag(tag *Tag) error {
+	return patient.TagContext(context.Background(), tag)
+}
+
+func (patient *Patient) TagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, patient.Client, tag, patient, true)
+}
+
+func (patient *Patient) Untag(tag *Tag) error {
+	return patient.UntagContext(context.Background(), tag)
+}
+
+func (patient *Patient) UntagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, patient.Client, tag, patient, false)
+}
+
+func (study *Study) Tag(tag *Tag) error {
+	return study.TagContext(context.Background(), tag)
+}
+
+func (study *Study) TagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, study.Client, tag, study, true)
+}
+
+func (study *Study) Untag(tag *Tag) error {
+	return study.UntagContext(context.Background(), tag)
+}
+
+func (study *Study) UntagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, study.Client, tag, study, false)
+}
+
+func (series *Series) Tag(tag *Tag) error {
+	return series.TagContext(context.Background(), tag)
+}
+
+func (series *Series) TagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, series.Client, tag, series, true)
+}
+
+func (series *Series) Untag(tag *Tag) error {
+	return series.UntagContext(context.Background(), tag)
+}
+
+func (series *Series) UntagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, series.Client, tag, series, false)
+}
+
+func (dataset *Dataset) Tag(tag *Tag) error {
+	return dataset.TagContext(context.Background(), tag)
+}
+
+func (dataset *Dataset) TagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, dataset.Client, tag, dataset, true)
+}
+
+func (dataset *Dataset) Untag(tag *Tag) error {
+	return dataset.UntagContext(context.Background(), tag)
+}
+
+func (dataset *Dataset) UntagContext(ctx context.Context, tag *Tag) error {
+	return assignTag(ctx, dataset.Client, tag, dataset, false)
+}
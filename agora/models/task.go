@@ -0,0 +1,268 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const TaskURL = "api/v2/task/"
+const TaskInfoURL = "api/v2/taskinfo/"
+
+const TypeTaskProgress ProgressType = "task_progress" // data: TaskInfo
+
+// the interval in which TaskInfo.Wait polls the state of the task
+var TASK_POLL_INTERVAL = 2 * time.Second
+
+var ErrTaskFailed = errors.New("the task failed")
+
+type TaskState int
+
+const (
+	TaskStateError     TaskState = -1
+	TaskStateCanceled  TaskState = -2
+	TaskStateScheduled TaskState = 1
+	TaskStateRunning   TaskState = 2
+	TaskStateFinished  TaskState = 3
+)
+
+func (state TaskState) String() string {
+	switch state {
+	case TaskStateError:
+		return "error"
+	case TaskStateCanceled:
+		return "canceled"
+	case TaskStateScheduled:
+		return "scheduled"
+	case TaskStateRunning:
+		return "running"
+	case TaskStateFinished:
+		return "finished"
+	}
+	return fmt.Sprintf("TaskState(%d)", int(state))
+}
+
+// IsDone returns true if the task will not change its state anymore
+func (state TaskState) IsDone() bool {
+	return state == TaskStateFinished || state == TaskStateError || state == TaskStateCanceled
+}
+
+type Task struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Inputs      []TaskInput  `json:"inputs"`
+	Outputs     []TaskOutput `json:"outputs"`
+	Owner       *int         `json:"owner"`
+	CreatedDate time.Time    `json:"created_date"`
+
+	http.BaseModel
+}
+
+type TaskInput struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+type TaskOutput struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type TaskInfo struct {
+	ID        int        `json:"id"`
+	Task      *int       `json:"task"`
+	Name      string     `json:"name"`
+	State     TaskState  `json:"state"`
+	Progress  int        `json:"progress"`
+	Error     string     `json:"error"`
+	User      *int       `json:"user"`
+	Host      *int       `json:"host"`
+	StartTime *time.Time `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+
+	http.BaseModel
+}
+
+type taskRunRequest struct {
+	Inputs map[string]interface{} `json:"inputs"`
+	Target *objectRef             `json:"target,omitempty"`
+	Host   *int                   `json:"host,omitempty"`
+}
+
+// Run starts the task. Input values which are models or slices of models (e.g. a *Dataset or []Dataset) are sent as
+// references, all other values as they are. target is the object the task runs on, it can be nil.
+func (task *Task) Run(inputs map[string]any, target Linkable) (*TaskInfo, error) {
+	return task.RunContext(context.Background(), inputs, target)
+}
+
+func (task *Task) RunContext(ctx context.Context, inputs map[string]any, target Linkable) (*TaskInfo, error) {
+	return task.RunOnHostContext(ctx, inputs, target, nil)
+}
+
+// RunOnHost starts the task on the given host. If host is nil Agora selects the host
+func (task *Task) RunOnHost(inputs map[string]any, target Linkable, host *Host) (*TaskInfo, error) {
+	return task.RunOnHostContext(context.Background(), inputs, target, host)
+}
+
+func (task *Task) RunOnHostContext(ctx context.Context, inputs map[string]any, target Linkable, host *Host) (*TaskInfo, error) {
+	data := taskRunRequest{Inputs: map[string]interface{}{}}
+	if host != nil {
+		data.Host = &host.ID
+	}
+	for key, value := range inputs {
+		input, err := taskInputValue(reflect.ValueOf(value))
+		if err != nil {
+			return nil, fmt.Errorf("task input \"%s\": %w", key, err)
+		}
+		data.Inputs[key] = input
+	}
+	if target != nil {
+		ref := newObjectRef(target)
+		data.Target = &ref
+	}
+
+	var taskInfo TaskInfo
+	path := fmt.Sprintf("%s%d/run/", TaskURL, task.ID)
+	err := task.Client.DoContext(ctx, "POST", path, data, &taskInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &taskInfo, nil
+}
+
+var linkableType = reflect.TypeOf((*Linkable)(nil)).Elem()
+
+// taskInputValue converts models (values, pointers and slices of them) to references. All other values are sent as
+// they are
+func taskInputValue(value reflect.Value) (any, error) {
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return nil, nil
+	}
+	if isLinkableType(value.Type()) {
+		return linkableRef(value)
+	}
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		elemType := value.Type().Elem()
+		if isLinkableType(elemType) || elemType.Kind() == reflect.Interface {
+			values := make([]any, value.Len())
+			for i := range values {
+				v, err := taskInputValue(value.Index(i))
+				if err != nil {
+					return nil, err
+				}
+				values[i] = v
+			}
+			return values, nil
+		}
+	}
+	return value.Interface(), nil
+}
+
+func isLinkableType(t reflect.Type) bool {
+	return t.Implements(linkableType) || (t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(linkableType))
+}
+
+func linkableRef(value reflect.Value) (objectRef, error) {
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return objectRef{}, fmt.Errorf("nil %s", value.Type())
+	}
+	if value.Kind() == reflect.Struct {
+		// LinkRef has a pointer receiver
+		if !value.CanAddr() {
+			copied := reflect.New(value.Type())
+			copied.Elem().Set(value)
+			value = copied.Elem()
+		}
+		value = value.Addr()
+	}
+	return newObjectRef(value.Interface().(Linkable)), nil
+}
+
+// Update reloads the state of the task run
+func (taskInfo *TaskInfo) Update() error {
+	return taskInfo.UpdateContext(context.Background())
+}
+
+func (taskInfo *TaskInfo) UpdateContext(ctx context.Context) error {
+	path := fmt.Sprintf("%s%d/", TaskInfoURL, taskInfo.ID)
+	return taskInfo.Client.GetAndParseContext(ctx, path, taskInfo)
+}
+
+// Wait polls the task run until it is done. It returns an error wrapping ErrTaskFailed if the task did not finish successfully
+func (taskInfo *TaskInfo) Wait(progressChan chan UploadProgress) error {
+	return taskInfo.WaitContext(context.Background(), progressChan)
+}
+
+func (taskInfo *TaskInfo) WaitContext(ctx context.Context, progressChan chan UploadProgress) error {
+	ticker := time.NewTicker(TASK_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for !taskInfo.State.IsDone() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			err := taskInfo.UpdateContext(ctx)
+			if err != nil {
+				if taskInfo.Client.IsTimeoutError(err) {
+					continue
+				}
+				return err
+			}
+			if progressChan != nil {
+				progressChan <- UploadProgress{Type: TypeTaskProgress, Data: *taskInfo}
+			}
+		}
+	}
+	if taskInfo.State != TaskStateFinished {
+		return fmt.Errorf("%w: task %d is %s: %s", ErrTaskFailed, taskInfo.ID, taskInfo.State, taskInfo.Error)
+	}
+	return nil
+}
+
+// GetLog returns the log output of the task run
+func (taskInfo *TaskInfo) GetLog() (string, error) {
+	return taskInfo.GetLogContext(context.Background())
+}
+
+func (taskInfo *TaskInfo) GetLogContext(ctx context.Context) (string, error) {
+	resp, err := taskInfo.Client.GetContext(ctx, fmt.Sprintf("%s%d/log/", TaskInfoURL, taskInfo.ID), -1)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", http.NewAPIError(resp)
+	}
+	log, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(log), nil
+}
+
+// GetResults returns the objects which were created by the task run
+func (taskInfo *TaskInfo) GetResults() ([]TypedObject, error) {
+	return taskInfo.GetResultsContext(context.Background())
+}
+
+func (taskInfo *TaskInfo) GetResultsContext(ctx context.Context) ([]TypedObject, error) {
+	var results []TypedObject
+	err := taskInfo.Client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/results/", TaskInfoURL, taskInfo.ID), &results)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/hex"
@@ -93,14 +94,6 @@ type ImportProgress struct {
 	Progress int         `json:"progress"`
 }
 
-type Datafile struct {
-	Id      int    `json:"state"`
-	Path    string `json:"path"`
-	Sha1    string `json:"sha1"`
-	Dataset int    `json:"dataset"`
-	Created bool   `json:"created"`
-}
-
 type ImportResult struct {
 	Datafiles      []Datafile `json:"datafiles"`
 	NrFiles        int
@@ -257,6 +250,10 @@ func NewUploadFile(path string, attachments []string) (UploadFile, error) {
 }
 
 func (importPackage *ImportPackage) Upload(inputFiles []UploadFile, progressChan chan UploadProgress) error {
+	return importPackage.UploadContext(context.Background(), inputFiles, progressChan)
+}
+
+func (importPackage *ImportPackage) UploadContext(ctx context.Context, inputFiles []UploadFile, progressChan chan UploadProgress) error {
 	progressChan <- UploadProgress{Type: TypeUploadStarted, Data: importPackage.Id}
 	filesToUpload, filesToZip, err := analysePaths(inputFiles)
 	if err != nil {
@@ -278,11 +275,6 @@ func (importPackage *ImportPackage) Upload(inputFiles []UploadFile, progressChan
 	parallelUploads := PARALLEL_UPLOADS
 	fake := false
 
-	apiKey, err := importPackage.Client.GetApiKey()
-	if err != nil {
-		return err
-	}
-
 	// Adding routines to workgroup and running then
 	fileCh := make(chan UploadFile, parallelUploads)
 	uploadBytesCh := make(chan UploadProgressTransferData, parallelUploads)
@@ -312,7 +304,7 @@ func (importPackage *ImportPackage) Upload(inputFiles []UploadFile, progressChan
 
 	for i := 0; i < parallelUploads; i++ {
 		wg.Add(1)
-		go uploadWorker(fileCh, uploadBytesCh, progressChan, requestUrl, apiKey, fake, wg)
+		go uploadWorker(ctx, importPackage.Client, fileCh, uploadBytesCh, progressChan, requestUrl, fake, wg)
 	}
 
 	tempDir, err := os.MkdirTemp("", "agora_interface_go")
@@ -323,7 +315,7 @@ func (importPackage *ImportPackage) Upload(inputFiles []UploadFile, progressChan
 
 	wg_upload_zip := new(sync.WaitGroup)
 	wg_upload_zip.Add(1)
-	go uploadFiles(fileCh, filesToUpload, wg_upload_zip)
+	go uploadFiles(ctx, fileCh, filesToUpload, wg_upload_zip)
 	if zipFilesSize > MAX_ZIP_SIZE {
 		// if there are a lot of files to zip then we split the zipping into 3 parts and process them in parallel
 		partSize := (len(filesToZip) + parallelUploads - 1) / parallelUploads
@@ -336,11 +328,11 @@ func (importPackage *ImportPackage) Upload(inputFiles []UploadFile, progressChan
 			part := filesToZip[start:end]
 
 			wg_upload_zip.Add(1)
-			go zipAndUpload(fileCh, i, part, tempDir, wg_upload_zip)
+			go zipAndUpload(ctx, fileCh, i, part, tempDir, wg_upload_zip)
 		}
 	} else {
 		wg_upload_zip.Add(1)
-		go zipAndUpload(fileCh, 0, filesToZip, tempDir, wg_upload_zip)
+		go zipAndUpload(ctx, fileCh, 0, filesToZip, tempDir, wg_upload_zip)
 	}
 	wg_upload_zip.Wait()
 
@@ -356,10 +348,14 @@ func (importPackage *ImportPackage) Upload(inputFiles []UploadFile, progressChan
 	// close progress channel
 	close(uploadBytesCh)
 	progressWg.Wait()
-	return nil
+	return ctx.Err()
 }
 
 func (importPackage *ImportPackage) Complete(targetFolderId int, jsonImportFile string, extractZipFile bool, wg *sync.WaitGroup) error {
+	return importPackage.CompleteContext(context.Background(), targetFolderId, jsonImportFile, extractZipFile, wg)
+}
+
+func (importPackage *ImportPackage) CompleteContext(ctx context.Context, targetFolderId int, jsonImportFile string, extractZipFile bool, wg *sync.WaitGroup) error {
 	path := fmt.Sprintf("/api/v1/import/%d/complete/", importPackage.Id)
 
 	// upload the json file is exists
@@ -371,15 +367,11 @@ func (importPackage *ImportPackage) Complete(targetFolderId int, jsonImportFile
 			return err
 		}
 		requestUrl := importPackage.Client.GetUrl(fmt.Sprintf("/api/v1/import/%d/upload/", importPackage.Id))
-		apiKey, err := importPackage.Client.GetApiKey()
-		if err != nil {
-			return err
-		}
 		file, err := NewUploadFile(jsonImportFile, nil)
 		if err != nil {
 			return err
 		}
-		_, err = uploadFile(nil, requestUrl, apiKey, file, false, 0)
+		_, err = uploadFile(ctx, importPackage.Client, nil, requestUrl, file, false, 0)
 		if err != nil {
 			return err
 		}
@@ -403,7 +395,7 @@ func (importPackage *ImportPackage) Complete(targetFolderId int, jsonImportFile
 		return err
 	}
 
-	resp, err := importPackage.Client.Post(path, bytes.NewBuffer(json_data), -1)
+	resp, err := importPackage.Client.PostContext(ctx, path, bytes.NewBuffer(json_data), -1)
 	if err != nil {
 		if wg != nil {
 			defer wg.Done()
@@ -415,18 +407,22 @@ func (importPackage *ImportPackage) Complete(targetFolderId int, jsonImportFile
 		if wg != nil {
 			defer wg.Done()
 		}
-		return fmt.Errorf("the \"complete\" request was invalid. http status = %d", resp.StatusCode)
+		return fmt.Errorf("the \"complete\" request was invalid: %w", agoraHttp.NewAPIError(resp))
 	}
 
 	if wg != nil {
 		// wait for completion
-		go importPackage.wait(importPackage.timeout, wg)
+		go importPackage.wait(ctx, importPackage.timeout, wg)
 	}
 
 	return nil
 }
 
 func (importPackage *ImportPackage) WaitForImport(progressChan chan UploadProgress) error {
+	return importPackage.WaitForImportContext(context.Background(), progressChan)
+}
+
+func (importPackage *ImportPackage) WaitForImportContext(ctx context.Context, progressChan chan UploadProgress) error {
 	timeout := importPackage.timeout
 
 	if importPackage.State == STATE_ERROR {
@@ -439,10 +435,12 @@ func (importPackage *ImportPackage) WaitForImport(progressChan chan UploadProgre
 
 	for {
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case <-time.After(timeout):
 			return errors.New("import progress timeout")
 		case <-ticker.C:
-			curProgress, err := importPackage.progress()
+			curProgress, err := importPackage.progress(ctx)
 			if err != nil {
 				if importPackage.Client.IsTimeoutError(err) {
 					continue
@@ -464,15 +462,19 @@ func (importPackage *ImportPackage) WaitForImport(progressChan chan UploadProgre
 }
 
 func (importPackage *ImportPackage) Result(progressChan chan UploadProgress) (*ImportResult, error) {
+	return importPackage.ResultContext(context.Background(), progressChan)
+}
+
+func (importPackage *ImportPackage) ResultContext(ctx context.Context, progressChan chan UploadProgress) (*ImportResult, error) {
 	var result *ImportResult
 	var progress *ImportProgress
 	var err error
 	if importPackage.importFinished {
-		result, err = importPackage.result()
+		result, err = importPackage.result(ctx)
 		if err != nil {
 			return nil, err
 		}
-		progress, err = importPackage.progress()
+		progress, err = importPackage.progress(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -556,6 +558,15 @@ func (importPackage *ImportPackage) Result(progressChan chan UploadProgress) (*I
 	return result, nil
 }
 
+// GetUser returns the user who created the import package
+func (importPackage *ImportPackage) GetUser() (*User, error) {
+	return importPackage.GetUserContext(context.Background())
+}
+
+func (importPackage *ImportPackage) GetUserContext(ctx context.Context) (*User, error) {
+	return getUser(ctx, importPackage.Client, importPackage.User)
+}
+
 func (importPackage *ImportPackage) SetUploadChunkSize(siz int64) {
 	if siz > 0 {
 		UPLOAD_CHUCK_SIZE = siz
@@ -566,22 +577,25 @@ func (importPackage *ImportPackage) SetTimeout(timeout time.Duration) {
 	importPackage.timeout = timeout
 }
 
-func (importPackage *ImportPackage) update() error {
+func (importPackage *ImportPackage) update(ctx context.Context) error {
 	requestUrl := importPackage.Client.GetUrl(fmt.Sprintf("%s%d/", ImportPackageURL, importPackage.Id))
-	err := importPackage.Client.GetAndParse(requestUrl, importPackage)
+	err := importPackage.Client.GetAndParseContext(ctx, requestUrl, importPackage)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (importPackage *ImportPackage) progress() (*ImportProgress, error) {
+func (importPackage *ImportPackage) progress(ctx context.Context) (*ImportProgress, error) {
 	requestUrl := importPackage.Client.GetUrl(fmt.Sprintf("%s%d/progress", ImportPackageURL, importPackage.Id))
-	resp, err := importPackage.Client.Get(requestUrl, -1)
+	resp, err := importPackage.Client.GetContext(ctx, requestUrl, -1)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, agoraHttp.NewAPIError(resp)
+	}
 	var curProgress ImportProgress
 	err = json.NewDecoder(resp.Body).Decode(&curProgress)
 	if err != nil {
@@ -590,9 +604,9 @@ func (importPackage *ImportPackage) progress() (*ImportProgress, error) {
 	return &curProgress, nil
 }
 
-func (importPackage *ImportPackage) result() (*ImportResult, error) {
+func (importPackage *ImportPackage) result(ctx context.Context) (*ImportResult, error) {
 	requestUrl := importPackage.Client.GetUrl(fmt.Sprintf("%s%d/result", ImportPackageURL, importPackage.Id))
-	resp, err := importPackage.Client.Get(requestUrl, -1)
+	resp, err := importPackage.Client.GetContext(ctx, requestUrl, -1)
 	if err != nil {
 		return nil, err
 	}
@@ -602,10 +616,14 @@ func (importPackage *ImportPackage) result() (*ImportResult, error) {
 	if err != nil {
 		return nil, errors.New("cannot get the upload results. Please update Agora to the newest version")
 	}
+	// the datafiles can be downloaded
+	for i := range result.Datafiles {
+		result.Datafiles[i].SetClient(importPackage.Client)
+	}
 	return &result, nil
 }
 
-func (importPackage *ImportPackage) wait(timeout time.Duration, wg *sync.WaitGroup) error {
+func (importPackage *ImportPackage) wait(ctx context.Context, timeout time.Duration, wg *sync.WaitGroup) error {
 	defer wg.Done()
 	if importPackage.State == STATE_FINISHED || importPackage.State == STATE_ERROR {
 		return nil
@@ -617,10 +635,12 @@ func (importPackage *ImportPackage) wait(timeout time.Duration, wg *sync.WaitGro
 
 	for {
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case <-time.After(timeout):
 			return errors.New("upload progress timeout")
 		case <-ticker.C:
-			err := importPackage.update()
+			err := importPackage.update(ctx)
 			if err != nil {
 				return err
 			}
@@ -723,18 +743,25 @@ func sha256HashBytes(data []byte) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-func uploadWorker(fileChan chan UploadFile, uploadBytesCh chan UploadProgressTransferData, progressChan chan UploadProgress, request_url string, api_key string, fake bool, wg *sync.WaitGroup) {
+func uploadWorker(ctx context.Context, client *agoraHttp.Client, fileChan chan UploadFile, uploadBytesCh chan UploadProgressTransferData, progressChan chan UploadProgress, request_url string, fake bool, wg *sync.WaitGroup) {
 	// Decreasing internal counter for wait-group as soon as goroutine finishes
 	defer wg.Done()
 
 	transferRate := int64(5 * 1024 * 1024)
 	for file := range fileChan {
+		if ctx.Err() != nil {
+			// keep draining the channel so that the producers do not block
+			if file.Delete {
+				os.Remove(file.SourcePath)
+			}
+			continue
+		}
 		progressChan <- UploadProgress{Type: TypeFileUploadStarted, Data: file}
-		transferRate, _ = uploadFile(uploadBytesCh, request_url, api_key, file, fake, transferRate)
+		transferRate, _ = uploadFile(ctx, client, uploadBytesCh, request_url, file, fake, transferRate)
 	}
 }
 
-func uploadFile(uploadBytesCh chan UploadProgressTransferData, request_url string, api_key string, file UploadFile, fake bool, transferRate int64) (int64, error) {
+func uploadFile(ctx context.Context, client *agoraHttp.Client, uploadBytesCh chan UploadProgressTransferData, request_url string, file UploadFile, fake bool, transferRate int64) (int64, error) {
 	fileUploadProgress := UploadProgressTransferData{File: file, BytesIncrement: 0, BytesTransfered: 0, channel: uploadBytesCh}
 	buffer := make([]byte, UPLOAD_CHUCK_SIZE)
 	if file.Delete {
@@ -770,13 +797,17 @@ func uploadFile(uploadBytesCh chan UploadProgressTransferData, request_url strin
 	// chunk number starts at 1
 	curChunkNr := 1
 
-	client := &http.Client{}
 	r, err := os.Open(mainFile)
 	if err != nil {
 		fileUploadProgress.Error(err)
 		return transferRate, err
 	}
 	for i := 0; i < totalChunks; i++ {
+		if err := ctx.Err(); err != nil {
+			fileUploadProgress.Error(err)
+			r.Close()
+			return transferRate, err
+		}
 		n, err := r.Read(buffer)
 		if err != nil {
 			fileUploadProgress.Error(err)
@@ -854,7 +885,7 @@ func uploadFile(uploadBytesCh chan UploadProgressTransferData, request_url strin
 		}
 
 		start := time.Now()
-		err = uploadChunk(client, request_url, api_key, values, filepath.Base(file.SourcePath), fake)
+		err = uploadChunk(ctx, client, request_url, values, filepath.Base(file.SourcePath), fake)
 		close(cancel)
 		if err != nil {
 			fileUploadProgress.Error(err)
@@ -895,7 +926,7 @@ func uploadFile(uploadBytesCh chan UploadProgressTransferData, request_url strin
 	return transferRate, nil
 }
 
-func uploadChunk(client *http.Client, url string, api_key string, values map[string]io.Reader, filename string, fake bool) (err error) {
+func uploadChunk(ctx context.Context, client *agoraHttp.Client, url string, values map[string]io.Reader, filename string, fake bool) (err error) {
 	// Prepare a form that you will submit to that URL.
 	var b bytes.Buffer
 	w := multipart.NewWriter(&b)
@@ -926,51 +957,56 @@ func uploadChunk(client *http.Client, url string, api_key string, values map[str
 	w.Close()
 
 	// Now that you have a form, you can submit it to your handler.
-	req, err := http.NewRequest("POST", url, &b)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &b)
 	if err != nil {
 		return err
 	}
 	// Don't forget to set the content type, this will contain the boundary.
+	// the authentication header is added by the client according to its connection
 	req.Header.Set("Content-Type", w.FormDataContentType())
-	if api_key != "" {
-		req.Header.Set("Authorization", "X-Agora-Api-Key "+api_key)
-	}
 
 	// Submit the request
 	if !fake {
-		res, err2 := client.Do(req)
+		// flow chunks can safely be sent again, therefore they are retried even though it is a POST request
+		res, err2 := client.SendRetryableRequest(req, 0)
 		if err2 != nil {
 			return err2
 		}
 		// drain and close the body so the underlying connection can be reused/released;
 		// leaving it open leaks a socket + readLoop/writeLoop goroutine per chunk
-		io.Copy(io.Discard, res.Body)
-		res.Body.Close()
+		defer res.Body.Close()
+		defer io.Copy(io.Discard, res.Body)
 		// Check the response
 		if res.StatusCode != http.StatusOK {
-			err2 = fmt.Errorf("bad status: %s", res.Status)
-			return err2
+			return agoraHttp.NewAPIError(res)
 		}
 	}
 
 	return nil
 }
 
-func uploadFiles(fileCh chan UploadFile, files_to_upload []UploadFile, wg *sync.WaitGroup) error {
+func uploadFiles(ctx context.Context, fileCh chan UploadFile, files_to_upload []UploadFile, wg *sync.WaitGroup) error {
 	defer wg.Done()
 
 	// Processing all links by spreading them to `free` goroutines
 	for _, file := range files_to_upload {
-		fileCh <- file
+		select {
+		case fileCh <- file:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 	return nil
 }
 
-func zipAndUpload(fileCh chan UploadFile, threadId int, files_to_zip []UploadFile, temp_dir string, wg *sync.WaitGroup) error {
+func zipAndUpload(ctx context.Context, fileCh chan UploadFile, threadId int, files_to_zip []UploadFile, temp_dir string, wg *sync.WaitGroup) error {
 	defer wg.Done()
 
 	index := 0
 	for index < len(files_to_zip) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		zip_filename := fmt.Sprintf("upload_%d_%d.agora_upload", threadId, index)
 		zip_path := filepath.Join(temp_dir, zip_filename)
 		zipfile, err := os.Create(zip_path)
@@ -981,6 +1017,9 @@ func zipAndUpload(fileCh chan UploadFile, threadId int, files_to_zip []UploadFil
 
 		w := zip.NewWriter(zipfile)
 		for _, file_to_zip := range files_to_zip[index:] {
+			if ctx.Err() != nil {
+				break
+			}
 			file, err := os.Open(file_to_zip.SourcePath)
 			if err != nil {
 				return err
@@ -1017,7 +1056,12 @@ func zipAndUpload(fileCh chan UploadFile, threadId int, files_to_zip []UploadFil
 		zipfile.Close()
 		upload_file := UploadFile{SourcePath: zip_path, TargetPath: zip_filename, Delete: true}
 		upload_file.setSize()
-		fileCh <- upload_file
+		select {
+		case fileCh <- upload_file:
+		case <-ctx.Done():
+			os.Remove(zip_path)
+			return ctx.Err()
+		}
 	}
 
 	return nil
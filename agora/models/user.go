@@ -0,0 +1,71 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GyroTools/gtagora-connector-go/internals/http"
+)
+
+const UserURL = "api/v1/user/"
+const GroupURL = "api/v1/group/"
+
+type User struct {
+	ID          int        `json:"id"`
+	Username    string     `json:"username"`
+	FirstName   string     `json:"first_name"`
+	LastName    string     `json:"last_name"`
+	Email       string     `json:"email"`
+	IsActive    bool       `json:"is_active"`
+	IsStaff     bool       `json:"is_staff"`
+	IsSuperuser bool       `json:"is_superuser"`
+	LastLogin   *time.Time `json:"last_login"`
+	DateJoined  *time.Time `json:"date_joined"`
+
+	http.BaseModel
+}
+
+type Group struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Users []int  `json:"users"`
+
+	http.BaseModel
+}
+
+// DisplayName returns the full name of the user or the username if the name is not set
+func (user *User) DisplayName() string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		return user.Username
+	}
+	return name
+}
+
+func getUser(ctx context.Context, client *http.Client, id int) (*User, error) {
+	var user User
+	err := client.GetAndParseContext(ctx, fmt.Sprintf("%s%d/", UserURL, id), &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUsers returns the members of the group
+func (group *Group) GetUsers() ([]User, error) {
+	return group.GetUsersContext(context.Background())
+}
+
+func (group *Group) GetUsersContext(ctx context.Context) ([]User, error) {
+	users := make([]User, 0, len(group.Users))
+	for _, id := range group.Users {
+		user, err := getUser(ctx, group.Client, id)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, nil
+}
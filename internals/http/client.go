@@ -1,6 +1,8 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -14,8 +16,16 @@ import (
 )
 
 type Client struct {
-	conn           Connection
-	defaultTimeout time.Duration
+	conn             Connection
+	defaultTimeout   time.Duration
+	defaultPageSize  int
+	transport        *http.Transport
+	transportOptions TransportOptions
+	tlsConfig        *tls.Config
+	retryPolicy      RetryPolicy
+	middlewares      []Middleware
+	rateLimiter      *RateLimiter
+	inFlight         chan struct{}
 }
 
 type ApiKeyResponse struct {
@@ -29,58 +39,96 @@ type BaseModel struct {
 	Client *Client `json:"-"`
 }
 
+// SetClient is called after a model has been decoded. Models which contain other models override it to wire them as well
+func (model *BaseModel) SetClient(client *Client) {
+	model.Client = client
+}
+
+type clientSetter interface {
+	SetClient(client *Client)
+}
+
 func NewClient(url string, apiKey string, verifyCert bool) *Client {
-	connection := &ApiKeyConnection{verifyCert: verifyCert, apiKey: apiKey, url: url}
-	return &Client{conn: connection, defaultTimeout: 10 * time.Second}
+	return NewClientWithConnection(NewApiKeyConnection(url, apiKey, verifyCert))
 }
 
 func NewPasswordClient(url string, username string, password string, verifyCert bool) *Client {
-	connection := PasswordConnection{verifyCert: verifyCert, username: username, password: password, url: url}
-	return &Client{conn: &connection}
+	return NewClientWithConnection(NewPasswordConnection(url, username, password, verifyCert))
+}
+
+func NewClientWithConnection(connection Connection) *Client {
+	client := &Client{conn: connection, defaultTimeout: 10 * time.Second, retryPolicy: DefaultRetryPolicy()}
+	client.SetTransportOptions(DefaultTransportOptions())
+	return client
+}
+
+func (client *Client) GetConnection() Connection {
+	return client.conn
 }
 
 func (client *Client) SetDefaultTimeout(timeout time.Duration) {
 	client.defaultTimeout = timeout
 }
 
+func (client *Client) SetDefaultPageSize(pageSize int) {
+	client.defaultPageSize = pageSize
+}
+
 func (client *Client) Ping() error {
-	resp, err := client.Get("/api/v1/version/", -1)
+	return client.PingContext(context.Background())
+}
+
+func (client *Client) PingContext(ctx context.Context) error {
+	resp, err := client.GetContext(ctx, "/api/v1/version/", -1)
 	if err != nil {
 		return err
-	} else if resp.StatusCode != 200 {
-		return fmt.Errorf("status code = %d", resp.StatusCode)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return NewAPIError(resp)
 	}
 	return nil
 }
 
 func (client *Client) CheckConnection() error {
-	resp, err := client.Get("/api/v1/user/current/", -1)
+	return client.CheckConnectionContext(context.Background())
+}
+
+func (client *Client) CheckConnectionContext(ctx context.Context) error {
+	resp, err := client.GetContext(ctx, "/api/v1/user/current/", -1)
 	if err != nil {
 		return err
-	} else if resp.StatusCode != 200 {
-		return fmt.Errorf("status code = %d", resp.StatusCode)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return NewAPIError(resp)
 	}
 	return nil
 }
 
 func (client *Client) GetApiKey() (string, error) {
+	return client.GetApiKeyContext(context.Background())
+}
+
+func (client *Client) GetApiKeyContext(ctx context.Context) (string, error) {
 	if apiConn, ok := client.conn.(*ApiKeyConnection); ok {
 		return apiConn.apiKey, nil
-	} else if _, ok := client.conn.(*PasswordConnection); ok {
+	} else if client.conn != nil {
 
-		err := client.Ping()
+		err := client.PingContext(ctx)
 		if err != nil {
 			return "", err
 		}
-		resp, err := client.Get("/api/v1/apikey/", -1)
+		resp, err := client.GetContext(ctx, "/api/v1/apikey/", -1)
 
 		if err != nil {
-			return "", nil
+			return "", err
 		}
+		defer resp.Body.Close()
 		if resp.StatusCode == 404 {
-			return "", errors.New("no api-key found. please create an api-key in your Agora user profile")
+			return "", fmt.Errorf("no api-key found. please create an api-key in your Agora user profile: %w", NewAPIError(resp))
 		} else if resp.StatusCode > 299 {
-			return "", fmt.Errorf("status code = %d", resp.StatusCode)
+			return "", NewAPIError(resp)
 		}
 
 		target := new(ApiKeyResponse)
@@ -91,7 +139,11 @@ func (client *Client) GetApiKey() (string, error) {
 }
 
 func (client *Client) GetAndParse(path string, target interface{}) error {
-	resp, err := client.Get(path, -1)
+	return client.GetAndParseContext(context.Background(), path, target)
+}
+
+func (client *Client) GetAndParseContext(ctx context.Context, path string, target interface{}) error {
+	resp, err := client.GetContext(ctx, path, -1)
 	if err != nil {
 		return err
 	}
@@ -100,7 +152,11 @@ func (client *Client) GetAndParse(path string, target interface{}) error {
 }
 
 func (client *Client) PostAndParse(path string, body io.Reader, target interface{}) error {
-	resp, err := client.Post(path, body, -1)
+	return client.PostAndParseContext(context.Background(), path, body, target)
+}
+
+func (client *Client) PostAndParseContext(ctx context.Context, path string, body io.Reader, target interface{}) error {
+	resp, err := client.PostContext(ctx, path, body, -1)
 	if err != nil {
 		return err
 	}
@@ -117,13 +173,17 @@ func (client *Client) IsTimeoutError(err error) bool {
 
 func (client *Client) parseResponse(resp *http.Response, target interface{}, path string) error {
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("status code = %d", resp.StatusCode)
+		return NewAPIError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
+	// e.g. 204 No Content
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
 
 	// try to detect paged responses by checking for a "results" key
 	var generic map[string]json.RawMessage
@@ -146,30 +206,39 @@ func (client *Client) parseResponse(resp *http.Response, target interface{}, pat
 		}
 	}
 
-	targetType := reflect.TypeOf(target)
-	targetValue := reflect.ValueOf(target)
+	client.wireModels(target, path)
+	return nil
+}
 
-	if targetType.Kind() == reflect.Ptr {
-		targetType = targetType.Elem()
+// wireModels sets the client of all models (structs embedding BaseModel) in target
+func (client *Client) wireModels(target interface{}, path string) {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() == reflect.Ptr {
 		targetValue = targetValue.Elem()
 	}
 
-	if targetType.Kind() == reflect.Slice {
+	if targetValue.Kind() == reflect.Slice {
 		for i := 0; i < targetValue.Len(); i++ {
-			elem := targetValue.Index(i)
-			if baseModel, ok := getBaseModelFromStruct(elem); ok {
-				baseModel.URL = path
-				baseModel.Client = client
-			}
+			client.wireModel(targetValue.Index(i), path)
 		}
 	} else {
-		if baseModel, ok := getBaseModelFromStruct(targetValue); ok {
-			baseModel.URL = path
-			baseModel.Client = client
-		}
+		client.wireModel(targetValue, path)
 	}
+}
 
-	return nil
+func (client *Client) wireModel(value reflect.Value, path string) {
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct || !value.CanAddr() {
+		return
+	}
+	if baseModel, ok := getBaseModelFromStruct(value); ok {
+		baseModel.URL = path
+	}
+	if setter, ok := value.Addr().Interface().(clientSetter); ok {
+		setter.SetClient(client)
+	}
 }
 
 func getBaseModelFromStruct(value reflect.Value) (*BaseModel, bool) {
@@ -184,14 +253,14 @@ func getBaseModelFromStruct(value reflect.Value) (*BaseModel, bool) {
 }
 
 func (client Client) GetUrl(path string) string {
-	u, err := url.Parse(client.conn.getUrl())
+	u, err := url.Parse(client.conn.GetUrl())
 	if err != nil {
-		return client.conn.getUrl() + path
+		return client.conn.GetUrl() + path
 	}
 	// Parse the provided path separately
 	parsedPath, err := url.Parse(path)
 	if err != nil {
-		return client.conn.getUrl() + path
+		return client.conn.GetUrl() + path
 	}
 
 	// Resolve the parsed path against the base URL
@@ -199,66 +268,95 @@ func (client Client) GetUrl(path string) string {
 	return resolvedURL
 }
 
-func handleNoCertificateCheck(check bool) {
-	if !check {
-		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	}
+func (client *Client) Get(path string, timeout time.Duration) (*http.Response, error) {
+	return client.GetContext(context.Background(), path, timeout)
 }
 
-func (client *Client) Get(path string, timeout time.Duration) (*http.Response, error) {
-	if client.conn != nil {
-		handleNoCertificateCheck(client.conn.verifyCertificate())
-	}
-	url := client.GetUrl(path)
-	if timeout == -1 {
-		timeout = client.defaultTimeout // Set the default timeout duration
-	}
-	httpClient := &http.Client{
-		Timeout: timeout,
-	}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
+func (client *Client) GetContext(ctx context.Context, path string, timeout time.Duration) (*http.Response, error) {
+	return client.request(ctx, "GET", path, nil, timeout)
+}
+
+func (client *Client) Post(path string, body io.Reader, timeout time.Duration) (*http.Response, error) {
+	return client.PostContext(context.Background(), path, body, timeout)
+}
+
+func (client *Client) PostContext(ctx context.Context, path string, body io.Reader, timeout time.Duration) (*http.Response, error) {
+	return client.request(ctx, "POST", path, body, timeout)
+}
+
+func (client *Client) Put(path string, body io.Reader, timeout time.Duration) (*http.Response, error) {
+	return client.PutContext(context.Background(), path, body, timeout)
+}
+
+func (client *Client) PutContext(ctx context.Context, path string, body io.Reader, timeout time.Duration) (*http.Response, error) {
+	return client.request(ctx, "PUT", path, body, timeout)
+}
+
+func (client *Client) Patch(path string, body io.Reader, timeout time.Duration) (*http.Response, error) {
+	return client.PatchContext(context.Background(), path, body, timeout)
+}
+
+func (client *Client) PatchContext(ctx context.Context, path string, body io.Reader, timeout time.Duration) (*http.Response, error) {
+	return client.request(ctx, "PATCH", path, body, timeout)
+}
+
+func (client *Client) Delete(path string, timeout time.Duration) (*http.Response, error) {
+	return client.DeleteContext(context.Background(), path, timeout)
+}
 
-	if client.conn != nil {
-		auth := client.conn.auth()
-		if auth != nil {
-			req.Header.Set(auth.Key, auth.Value)
+func (client *Client) DeleteContext(ctx context.Context, path string, timeout time.Duration) (*http.Response, error) {
+	return client.request(ctx, "DELETE", path, nil, timeout)
+}
+
+// Do sends a request with an arbitrary method. The body is marshalled to json unless it is an io.Reader or a []byte.
+// If target is not nil the response is decoded into it like in GetAndParse. Otherwise the response body is discarded.
+func (client *Client) Do(method string, path string, body interface{}, target interface{}) error {
+	return client.DoContext(context.Background(), method, path, body, target)
+}
+
+func (client *Client) DoContext(ctx context.Context, method string, path string, body interface{}, target interface{}) error {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return err
 		}
+		reader = bytes.NewReader(jsonData)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := httpClient.Do(req)
+
+	resp, err := client.request(ctx, method, path, reader, -1)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return resp, err
+	defer resp.Body.Close()
+	if target == nil {
+		if resp.StatusCode >= 400 {
+			return NewAPIError(resp)
+		}
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return client.parseResponse(resp, target, path)
 }
 
-func (client *Client) Post(path string, body io.Reader, timeout time.Duration) (*http.Response, error) {
-	if client.conn != nil {
-		handleNoCertificateCheck(client.conn.verifyCertificate())
-	}
+func (client *Client) request(ctx context.Context, method string, path string, body io.Reader, timeout time.Duration) (*http.Response, error) {
 	url := client.GetUrl(path)
 	if timeout == -1 {
-		timeout = client.defaultTimeout
-	}
-	httpClient := &http.Client{
-		Timeout: timeout,
+		timeout = client.defaultTimeout // Set the default timeout duration
 	}
-	req, err := http.NewRequest("POST", url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
 
-	if client.conn != nil {
-		auth := client.conn.auth()
-		if auth != nil {
-			req.Header.Set(auth.Key, auth.Value)
-		}
-	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := httpClient.Do(req)
+	resp, err := client.SendRequest(req, timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -1,16 +1,70 @@
 package http
 
-import "encoding/base64"
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+)
 
 type Auth struct {
 	Key   string
 	Value string
 }
 
+// Connection describes how a client reaches and authenticates against an Agora server. Implement it to plug in
+// custom authentication schemes.
 type Connection interface {
-	auth() *Auth
-	getUrl() string
-	verifyCertificate() bool
+	GetUrl() string
+	VerifyCertificate() bool
+	// Authenticate adds the credentials to an outgoing request
+	Authenticate(req *http.Request) error
+}
+
+// LoginConnection is implemented by connections which have to talk to the server before they can authenticate
+// requests (e.g. a session login). The client calls Login before the first request.
+type LoginConnection interface {
+	Connection
+	IsLoggedIn() bool
+	Login(ctx context.Context, client *http.Client) error
+}
+
+// ResponseConnection is implemented by connections which have to see the responses, e.g. to store rotated session
+// cookies. HandleResponse returns true if the credentials were rejected and the request should be sent again after a
+// new login.
+type ResponseConnection interface {
+	HandleResponse(resp *http.Response) bool
+}
+
+func (client *Client) handleResponse(resp *http.Response) bool {
+	if responseConn, ok := client.conn.(ResponseConnection); ok {
+		return responseConn.HandleResponse(resp)
+	}
+	return false
+}
+
+func (client *Client) authenticate(req *http.Request, httpClient *http.Client) error {
+	if client.conn == nil {
+		return nil
+	}
+	if loginConn, ok := client.conn.(LoginConnection); ok && !loginConn.IsLoggedIn() {
+		if err := loginConn.Login(req.Context(), httpClient); err != nil {
+			return err
+		}
+	}
+	return client.conn.Authenticate(req)
+}
+
+func setAuth(req *http.Request, auth *Auth) {
+	if auth != nil {
+		req.Header.Set(auth.Key, auth.Value)
+	}
 }
 
 type ApiKeyConnection struct {
@@ -19,6 +73,10 @@ type ApiKeyConnection struct {
 	apiKey     string
 }
 
+func NewApiKeyConnection(url string, apiKey string, verifyCert bool) *ApiKeyConnection {
+	return &ApiKeyConnection{url: url, apiKey: apiKey, verifyCert: verifyCert}
+}
+
 func (c *ApiKeyConnection) auth() *Auth {
 	if len(c.apiKey) > 0 {
 		key := "Authorization"
@@ -28,11 +86,16 @@ func (c *ApiKeyConnection) auth() *Auth {
 	return nil
 }
 
-func (c *ApiKeyConnection) getUrl() string {
+func (c *ApiKeyConnection) Authenticate(req *http.Request) error {
+	setAuth(req, c.auth())
+	return nil
+}
+
+func (c *ApiKeyConnection) GetUrl() string {
 	return c.url
 }
 
-func (c *ApiKeyConnection) verifyCertificate() bool {
+func (c *ApiKeyConnection) VerifyCertificate() bool {
 	return c.verifyCert
 }
 
@@ -43,6 +106,10 @@ type PasswordConnection struct {
 	password   string
 }
 
+func NewPasswordConnection(url string, username string, password string, verifyCert bool) *PasswordConnection {
+	return &PasswordConnection{url: url, username: username, password: password, verifyCert: verifyCert}
+}
+
 func (c PasswordConnection) basicAuth(username string, password string) string {
 	auth := username + ":" + password
 	return base64.StdEncoding.EncodeToString([]byte(auth))
@@ -57,10 +124,246 @@ func (c PasswordConnection) auth() *Auth {
 	return nil
 }
 
-func (c PasswordConnection) getUrl() string {
+func (c PasswordConnection) Authenticate(req *http.Request) error {
+	setAuth(req, c.auth())
+	return nil
+}
+
+func (c PasswordConnection) GetUrl() string {
+	return c.url
+}
+
+func (c PasswordConnection) VerifyCertificate() bool {
+	return c.verifyCert
+}
+
+// TokenSource returns a (bearer) token. It is called for every request, caching and refreshing is up to the implementation
+type TokenSource func(ctx context.Context) (string, error)
+
+// TokenConnection authenticates with "Authorization: Bearer <token>", e.g. for OAuth2 access tokens
+type TokenConnection struct {
+	url        string
+	verifyCert bool
+	source     TokenSource
+}
+
+func NewTokenConnection(url string, token string, verifyCert bool) *TokenConnection {
+	source := func(ctx context.Context) (string, error) {
+		return token, nil
+	}
+	return NewTokenSourceConnection(url, source, verifyCert)
+}
+
+func NewTokenSourceConnection(url string, source TokenSource, verifyCert bool) *TokenConnection {
+	return &TokenConnection{url: url, source: source, verifyCert: verifyCert}
+}
+
+func (c *TokenConnection) Authenticate(req *http.Request) error {
+	token, err := c.source(req.Context())
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+func (c *TokenConnection) GetUrl() string {
+	return c.url
+}
+
+func (c *TokenConnection) VerifyCertificate() bool {
+	return c.verifyCert
+}
+
+// CredentialHelper returns the credentials on demand, e.g. from a secret store. A nil Auth sends the request unauthenticated
+type CredentialHelper func(ctx context.Context) (*Auth, error)
+
+type CredentialHelperConnection struct {
+	url        string
+	verifyCert bool
+	helper     CredentialHelper
+}
+
+func NewCredentialHelperConnection(url string, helper CredentialHelper, verifyCert bool) *CredentialHelperConnection {
+	return &CredentialHelperConnection{url: url, helper: helper, verifyCert: verifyCert}
+}
+
+func (c *CredentialHelperConnection) Authenticate(req *http.Request) error {
+	auth, err := c.helper(req.Context())
+	if err != nil {
+		return err
+	}
+	setAuth(req, auth)
+	return nil
+}
+
+func (c *CredentialHelperConnection) GetUrl() string {
+	return c.url
+}
+
+func (c *CredentialHelperConnection) VerifyCertificate() bool {
+	return c.verifyCert
+}
+
+const DefaultLoginPath = "/accounts/login/"
+
+// SessionConnection logs in with username and password like the web interface and authenticates the requests with
+// the session cookie and the CSRF token
+type SessionConnection struct {
+	url        string
+	verifyCert bool
+	username   string
+	password   string
+	loginPath  string
+	jar        *cookiejar.Jar
+	loggedIn   bool
+	// the session was accepted by the server at least once. A fresh session which is rejected lacks a permission and
+	// has not expired
+	confirmed bool
+	// mutex protects loggedIn and confirmed. loginMutex serializes the logins and is held during the login requests,
+	// therefore it must never be taken while a response is open
+	mutex      sync.Mutex
+	loginMutex sync.Mutex
+}
+
+func NewSessionConnection(url string, username string, password string, verifyCert bool) *SessionConnection {
+	jar, _ := cookiejar.New(nil)
+	return &SessionConnection{url: url, username: username, password: password, verifyCert: verifyCert, loginPath: DefaultLoginPath, jar: jar}
+}
+
+func (c *SessionConnection) SetLoginPath(path string) {
+	c.loginPath = path
+}
+
+func (c *SessionConnection) IsLoggedIn() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.loggedIn
+}
+
+func (c *SessionConnection) Login(ctx context.Context, client *http.Client) error {
+	c.loginMutex.Lock()
+	defer c.loginMutex.Unlock()
+	if c.IsLoggedIn() {
+		return nil
+	}
+
+	loginUrl, err := url.Parse(strings.TrimSuffix(c.url, "/") + c.loginPath)
+	if err != nil {
+		return err
+	}
+	sessionClient := *client
+	sessionClient.Jar = c.jar
+
+	// the first request sets the csrf cookie
+	req, err := http.NewRequestWithContext(ctx, "GET", loginUrl.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := sessionClient.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	csrfToken := c.cookie(loginUrl, "csrftoken")
+	if csrfToken == "" {
+		return errors.New("the login page did not return a csrf token")
+	}
+
+	form := url.Values{}
+	form.Set("username", c.username)
+	form.Set("password", c.password)
+	form.Set("csrfmiddlewaretoken", csrfToken)
+	req, err = http.NewRequestWithContext(ctx, "POST", loginUrl.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", loginUrl.String())
+	req.Header.Set("X-CSRFToken", csrfToken)
+	resp, err = sessionClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return NewAPIError(resp)
+	}
+	if c.cookie(loginUrl, "sessionid") == "" {
+		return fmt.Errorf("login of user \"%s\" failed", c.username)
+	}
+	c.mutex.Lock()
+	c.loggedIn = true
+	c.confirmed = false
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *SessionConnection) cookie(u *url.URL, name string) string {
+	for _, cookie := range c.jar.Cookies(u) {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func (c *SessionConnection) Authenticate(req *http.Request) error {
+	for _, cookie := range c.jar.Cookies(req.URL) {
+		req.AddCookie(cookie)
+	}
+	// django requires the csrf token for all unsafe methods
+	if csrfToken := c.cookie(req.URL, "csrftoken"); csrfToken != "" {
+		req.Header.Set("X-CSRFToken", csrfToken)
+		if req.Header.Get("Referer") == "" {
+			req.Header.Set("Referer", c.url)
+		}
+	}
+	return nil
+}
+
+// HandleResponse stores cookies which the server rotated. A rejected request (401 or 403) is sent again if it did not
+// carry the current session. If it did, the session expired and a new login is required, unless the session is fresh
+// and the request is simply not permitted.
+func (c *SessionConnection) HandleResponse(resp *http.Response) bool {
+	if resp.Request == nil {
+		return false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	current := c.cookie(resp.Request.URL, "sessionid")
+	sent := ""
+	if cookie, err := resp.Request.Cookie("sessionid"); err == nil {
+		sent = cookie.Value
+	}
+	if cookies := resp.Cookies(); len(cookies) > 0 {
+		c.jar.SetCookies(resp.Request.URL, cookies)
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+		if c.loggedIn && sent == current {
+			c.confirmed = true
+		}
+		return false
+	}
+	if !c.loggedIn || sent != current {
+		// another request already found the session expired or logged in again
+		return true
+	}
+	if !c.confirmed {
+		return false
+	}
+	c.loggedIn = false
+	return true
+}
+
+func (c *SessionConnection) GetUrl() string {
 	return c.url
 }
 
-func (c PasswordConnection) verifyCertificate() bool {
+func (c *SessionConnection) VerifyCertificate() bool {
 	return c.verifyCert
 }
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrForbidden         = errors.New("forbidden")
+	ErrConflict          = errors.New("conflict")
+	ErrServerUnavailable = errors.New("server unavailable")
+)
+
+// maximum number of bytes of an error body which are kept
+const maxErrorBodySize = 1024 * 1024
+
+// APIError is returned for every response with an unexpected status code. It can be checked with errors.Is against
+// the sentinel errors (e.g. ErrNotFound) or unpacked with errors.As.
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// the message of the server. Either the "detail" of the Django REST error or a summary of the field errors
+	Detail string
+	// the decoded json error body (usually a map[string]interface{}). nil if the body is not json
+	Body    interface{}
+	RawBody []byte
+}
+
+func NewAPIError(resp *http.Response) *APIError {
+	apiError := &APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		apiError.Method = resp.Request.Method
+		if resp.Request.URL != nil {
+			apiError.URL = resp.Request.URL.String()
+		}
+	}
+	if resp.Body != nil {
+		apiError.RawBody, _ = io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	}
+	if len(apiError.RawBody) > 0 && json.Unmarshal(apiError.RawBody, &apiError.Body) == nil {
+		apiError.Detail = errorDetail(apiError.Body)
+	}
+	return apiError
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("status code = %d", e.StatusCode)
+	if e.Method != "" || e.URL != "" {
+		msg = fmt.Sprintf("%s %s: %s", e.Method, e.URL, msg)
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrServerUnavailable:
+		return e.StatusCode == http.StatusBadGateway || e.StatusCode == http.StatusServiceUnavailable || e.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
+
+// errorDetail converts the Django REST framework error formats ({"detail": "..."}, {"field": ["..."]} or ["..."]) into a single message
+func errorDetail(body interface{}) string {
+	switch value := body.(type) {
+	case string:
+		return value
+	case []interface{}:
+		var messages []string
+		for _, item := range value {
+			if msg := errorDetail(item); msg != "" {
+				messages = append(messages, msg)
+			}
+		}
+		return strings.Join(messages, ", ")
+	case map[string]interface{}:
+		if detail, ok := value["detail"]; ok {
+			return errorDetail(detail)
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var messages []string
+		for _, key := range keys {
+			if msg := errorDetail(value[key]); msg != "" {
+				messages = append(messages, fmt.Sprintf("%s: %s", key, msg))
+			}
+		}
+		return strings.Join(messages, "; ")
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RoundTripperFunc is an adapter to use a function as http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the round tripper of the next middleware (or the transport). Like every http.RoundTripper it must
+// not modify the request, use req.Clone instead.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Use adds middlewares to the client. They are applied to every outgoing request (including retries, uploads and
+// import polling) in the order in which they were added: the first middleware sees the request first.
+func (client *Client) Use(middlewares ...Middleware) {
+	client.middlewares = append(client.middlewares, middlewares...)
+}
+
+func (client *Client) roundTripper() http.RoundTripper {
+	roundTripper := client.limitedTransport()
+	for i := len(client.middlewares) - 1; i >= 0; i-- {
+		roundTripper = client.middlewares[i](roundTripper)
+	}
+	return roundTripper
+}
+
+// HeaderMiddleware sets a header on every request which does not already have it
+func HeaderMiddleware(key string, value string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(key) == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set(key, value)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func UserAgentMiddleware(userAgent string) Middleware {
+	return HeaderMiddleware("User-Agent", userAgent)
+}
+
+// TimingMiddleware calls fn after every request with the duration of the round trip. resp is nil if err is not nil
+func TimingMiddleware(fn func(req *http.Request, resp *http.Response, err error, duration time.Duration)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			fn(req, resp, err, time.Since(start))
+			return resp, err
+		})
+	}
+}
+
+// LoggingMiddleware logs every request. The credentials in the authentication headers are redacted
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return TimingMiddleware(func(req *http.Request, resp *http.Response, err error, duration time.Duration) {
+		auth := redactAuthorization(req.Header.Get("Authorization"))
+		if err != nil {
+			logger.Printf("%s %s auth=%s error=%q (%s)", req.Method, req.URL.Redacted(), auth, err.Error(), duration)
+		} else {
+			logger.Printf("%s %s auth=%s status=%d (%s)", req.Method, req.URL.Redacted(), auth, resp.StatusCode, duration)
+		}
+	})
+}
+
+func redactAuthorization(value string) string {
+	if value == "" {
+		return "none"
+	}
+	// keep the scheme (e.g. "Bearer") but never the credentials
+	if i := strings.LastIndex(value, " "); i > 0 {
+		return value[:i] + " ***"
+	}
+	return "***"
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+const DefaultPageSize = 100
+
+// Paginator iterates over a paged list endpoint by following the "next" links of the server. Pages are only fetched
+// when they are needed:
+//
+//	paginator := folder.IterateItems(ctx, 0)
+//	for paginator.Next() {
+//		item := paginator.Value()
+//	}
+//	if err := paginator.Err(); err != nil {
+//		...
+//	}
+type Paginator[T any] struct {
+	ctx     context.Context
+	client  *Client
+	method  string
+	body    []byte
+	next    string
+	count   int
+	page    []T
+	index   int
+	current T
+	err     error
+	started bool
+}
+
+type page struct {
+	Count   int             `json:"count"`
+	Next    *string         `json:"next"`
+	Results json.RawMessage `json:"results"`
+}
+
+func NewPaginator[T any](ctx context.Context, client *Client, path string, pageSize int) *Paginator[T] {
+	if pageSize <= 0 {
+		pageSize = client.defaultPageSize
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if u, err := url.Parse(path); err == nil {
+		query := u.Query()
+		query.Set("limit", strconv.Itoa(pageSize))
+		query.Set("offset", "0")
+		u.RawQuery = query.Encode()
+		path = u.String()
+	}
+	return &Paginator[T]{ctx: ctx, client: client, method: "GET", next: path}
+}
+
+// NewPostPaginator is like NewPaginator but every page is requested with a POST of body marshalled to json, e.g. for
+// queries which are too complex for query parameters
+func NewPostPaginator[T any](ctx context.Context, client *Client, path string, body interface{}, pageSize int) (*Paginator[T], error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	p := NewPaginator[T](ctx, client, path, pageSize)
+	p.method = "POST"
+	p.body = jsonData
+	return p, nil
+}
+
+// Next advances to the next element. It returns false when there are no more elements or an error occurred
+func (p *Paginator[T]) Next() bool {
+	for p.index >= len(p.page) {
+		if p.err != nil || (p.started && p.next == "") {
+			return false
+		}
+		p.err = p.fetch()
+		if p.err != nil {
+			return false
+		}
+	}
+	p.current = p.page[p.index]
+	p.index++
+	return true
+}
+
+func (p *Paginator[T]) Value() T {
+	return p.current
+}
+
+func (p *Paginator[T]) Err() error {
+	return p.err
+}
+
+// Count returns the total number of elements reported by the server. The first page is fetched if necessary
+func (p *Paginator[T]) Count() (int, error) {
+	if !p.started {
+		p.err = p.fetch()
+	}
+	return p.count, p.err
+}
+
+// All fetches all remaining elements
+func (p *Paginator[T]) All() ([]T, error) {
+	var all []T
+	for p.Next() {
+		all = append(all, p.Value())
+	}
+	return all, p.Err()
+}
+
+// nextPath keeps only the path and the query of the "next" link. Behind a TLS terminating proxy the server often returns
+// an http:// link or an internal host name and the credentials must not be sent there
+func nextPath(next string) (string, error) {
+	u, err := url.Parse(next)
+	if err != nil {
+		return "", err
+	}
+	return u.RequestURI(), nil
+}
+
+func (p *Paginator[T]) fetch() error {
+	path := p.next
+	var requestBody io.Reader
+	if p.body != nil {
+		requestBody = bytes.NewReader(p.body)
+	}
+	resp, err := p.client.request(p.ctx, p.method, path, requestBody, -1)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return NewAPIError(resp)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var results []T
+	var curPage page
+	if err := json.Unmarshal(body, &curPage); err == nil && curPage.Results != nil {
+		if err := json.Unmarshal(curPage.Results, &results); err != nil {
+			return err
+		}
+		p.next = ""
+		if curPage.Next != nil {
+			if p.next, err = nextPath(*curPage.Next); err != nil {
+				return err
+			}
+		}
+		p.count = curPage.Count
+	} else {
+		// the endpoint is not paginated and returns all elements at once
+		if err := json.Unmarshal(body, &results); err != nil {
+			return err
+		}
+		p.next = ""
+		p.count = len(results)
+	}
+	p.client.wireModels(&results, path)
+
+	p.started = true
+	p.page = results
+	p.index = 0
+	return nil
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket which allows requestsPerSecond requests on average and bursts of up to burst requests
+type RateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: requestsPerSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a request is allowed or the context is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mutex.Lock()
+		now := time.Now()
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens -= 1
+			l.mutex.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mutex.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// SetRateLimit limits the number of requests per second of this client and all models created from it. A
+// requestsPerSecond <= 0 disables the limit
+func (client *Client) SetRateLimit(requestsPerSecond float64, burst int) {
+	if requestsPerSecond <= 0 {
+		client.rateLimiter = nil
+		return
+	}
+	client.rateLimiter = NewRateLimiter(requestsPerSecond, burst)
+}
+
+// SetMaxInFlight limits the number of concurrent requests. A request is in flight until its response body is closed.
+// A value <= 0 disables the limit
+func (client *Client) SetMaxInFlight(maxInFlight int) {
+	if maxInFlight <= 0 {
+		client.inFlight = nil
+		return
+	}
+	client.inFlight = make(chan struct{}, maxInFlight)
+}
+
+// limitedTransport applies the rate limit and the concurrency cap to every request which is sent over the transport
+func (client *Client) limitedTransport() http.RoundTripper {
+	limiter := client.rateLimiter
+	inFlight := client.inFlight
+	next := client.transport
+	if limiter == nil && inFlight == nil {
+		return next
+	}
+
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		release := func() {}
+		if inFlight != nil {
+			select {
+			case inFlight <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			var once sync.Once
+			release = func() {
+				once.Do(func() { <-inFlight })
+			}
+		}
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				release()
+				return nil, err
+			}
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+		return resp, nil
+	})
+}
+
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
@@ -0,0 +1,198 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type RetryPolicy struct {
+	// total number of attempts including the first one. A value <= 1 disables retries
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// random deviation of the backoff as a fraction (0.2 = +/- 20%)
+	Jitter            float64
+	RetryStatusCodes  []int
+	RespectRetryAfter bool
+	// requests with these methods are retried automatically. Others only if they are sent with SendRetryableRequest
+	IdempotentMethods []string
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:       4,
+		InitialBackoff:    500 * time.Millisecond,
+		MaxBackoff:        30 * time.Second,
+		Multiplier:        2,
+		Jitter:            0.2,
+		RetryStatusCodes:  []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		RespectRetryAfter: true,
+		IdempotentMethods: []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete},
+	}
+}
+
+func NoRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+func (policy RetryPolicy) IsIdempotent(method string) bool {
+	for _, m := range policy.IdempotentMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (policy RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		// a rejected certificate will not change on the next attempt. Everything else (timeouts, reset connections, ...)
+		// is considered transient. A cancelled context of the caller is handled in send
+		return !isCertificateError(err)
+	}
+	for _, code := range policy.RetryStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func isCertificateError(err error) bool {
+	var verificationError *tls.CertificateVerificationError
+	var unknownAuthorityError x509.UnknownAuthorityError
+	var hostnameError x509.HostnameError
+	var invalidError x509.CertificateInvalidError
+	return errors.Is(err, errPinMismatch) || errors.As(err, &verificationError) || errors.As(err, &unknownAuthorityError) ||
+		errors.As(err, &hostnameError) || errors.As(err, &invalidError)
+}
+
+// Backoff returns the time to wait before the next attempt. attempt is the number of the failed attempt (starting at 1).
+// resp may be nil, otherwise its Retry-After header is respected
+func (policy RetryPolicy) Backoff(attempt int, resp *http.Response) time.Duration {
+	if policy.RespectRetryAfter && resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			// the server must not be able to stall the client for an arbitrary time
+			if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+				wait = policy.MaxBackoff
+			}
+			return wait
+		}
+	}
+	multiplier := policy.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	wait := float64(policy.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if policy.MaxBackoff > 0 && wait > float64(policy.MaxBackoff) {
+		wait = float64(policy.MaxBackoff)
+	}
+	if policy.Jitter > 0 {
+		wait = wait * (1 - policy.Jitter + 2*policy.Jitter*rand.Float64())
+	}
+	return time.Duration(wait)
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func (client *Client) SetRetryPolicy(policy RetryPolicy) {
+	client.retryPolicy = policy
+}
+
+func (client *Client) GetRetryPolicy() RetryPolicy {
+	return client.retryPolicy
+}
+
+// SendRequest sends the request over the shared transport of the client. Requests with an idempotent method are retried
+// according to the retry policy. A timeout of 0 means no timeout.
+func (client *Client) SendRequest(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	return client.send(req, timeout, client.retryPolicy.IsIdempotent(req.Method))
+}
+
+// SendRetryableRequest is like SendRequest but retries the request regardless of its method. Use it only for requests
+// which are safe to resend (e.g. flow chunks)
+func (client *Client) SendRetryableRequest(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	return client.send(req, timeout, true)
+}
+
+func (client *Client) send(req *http.Request, timeout time.Duration, retry bool) (*http.Response, error) {
+	httpClient := client.GetHttpClient(timeout)
+	policy := client.retryPolicy
+
+	maxAttempts := 1
+	// a request body can only be sent again if it can be recreated
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if retry && replayable && policy.MaxAttempts > 1 {
+		maxAttempts = policy.MaxAttempts
+	}
+
+	ctx := req.Context()
+	sent := false
+	relogin := false
+	for attempt := 1; ; attempt++ {
+		// every attempt is authenticated on a copy, otherwise the credentials of the previous attempt would be sent as well
+		attemptReq := req.Clone(ctx)
+		if sent && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+
+		// authenticate every attempt since the credentials might have changed in the meantime (e.g. a refreshed token)
+		if err := client.authenticate(attemptReq, httpClient); err != nil {
+			return nil, err
+		}
+		resp, err := httpClient.Do(attemptReq)
+		sent = true
+		// the session expired: login again and resend the request once. This does not count as a failed attempt
+		if err == nil && client.handleResponse(resp) && !relogin && replayable {
+			relogin = true
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			attempt--
+			continue
+		}
+		if attempt >= maxAttempts || ctx.Err() != nil || !policy.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		wait := policy.Backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var errPinMismatch = errors.New("the server certificate does not match any pinned public key")
+
+type TLSOptions struct {
+	// PEM encoded CA certificates which are trusted in addition to the system roots
+	CAFile string
+	CAPEM  []byte
+	// client certificate for mutual TLS. Either as files or as already loaded certificates
+	ClientCertFile     string
+	ClientKeyFile      string
+	ClientCertificates []tls.Certificate
+	// e.g. tls.VersionTLS12. 0 uses the Go default
+	MinVersion uint16
+	// base64 encoded SHA-256 hashes of the SubjectPublicKeyInfo ("sha256/" prefix is optional).
+	// If set, at least one certificate of the verified server chain must match. If the certificate is not verified the
+	// pin must match the server certificate itself
+	PinnedSPKIHashes []string
+}
+
+func buildTLSConfig(opts TLSOptions, verifyCert bool) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: !verifyCert,
+		MinVersion:         opts.MinVersion,
+	}
+
+	if opts.CAFile != "" || len(opts.CAPEM) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if opts.CAFile != "" {
+			pem, err := os.ReadFile(opts.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in \"%s\"", opts.CAFile)
+			}
+		}
+		if len(opts.CAPEM) > 0 && !pool.AppendCertsFromPEM(opts.CAPEM) {
+			return nil, errors.New("no certificates found in the CA PEM data")
+		}
+		config.RootCAs = pool
+	}
+
+	config.Certificates = append(config.Certificates, opts.ClientCertificates...)
+	if opts.ClientCertFile != "" || opts.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertFile, opts.ClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = append(config.Certificates, cert)
+	}
+
+	if len(opts.PinnedSPKIHashes) > 0 {
+		pins := make(map[string]bool, len(opts.PinnedSPKIHashes))
+		for _, pin := range opts.PinnedSPKIHashes {
+			pins[strings.TrimPrefix(pin, "sha256/")] = true
+		}
+		// VerifyConnection is also called when InsecureSkipVerify is set, therefore pinning works with self-signed certificates
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			for _, cert := range pinCandidates(state, verifyCert) {
+				hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				if pins[base64.StdEncoding.EncodeToString(hash[:])] {
+					return nil
+				}
+			}
+			return errPinMismatch
+		}
+	}
+	return config, nil
+}
+
+// pinCandidates returns the certificates which may match a pin. The peer certificates are sent by the server and are
+// not necessarily part of the verified chain, therefore only verified chains are used. Without verification only the
+// leaf is used since it is the only certificate the server proves to own during the handshake.
+func pinCandidates(state tls.ConnectionState, verifyCert bool) []*x509.Certificate {
+	if !verifyCert {
+		if len(state.PeerCertificates) == 0 {
+			return nil
+		}
+		return state.PeerCertificates[:1]
+	}
+	var certs []*x509.Certificate
+	for _, chain := range state.VerifiedChains {
+		certs = append(certs, chain...)
+	}
+	return certs
+}
+
+// SetTLSOptions configures TLS for this client only. The options also apply to the uploads since they share the transport.
+func (client *Client) SetTLSOptions(opts TLSOptions) error {
+	config, err := buildTLSConfig(opts, client.verifyCertificate())
+	if err != nil {
+		return err
+	}
+	client.tlsConfig = config
+	client.SetTransportOptions(client.transportOptions)
+	return nil
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+type TransportOptions struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	KeepAlive           time.Duration
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	EnableHTTP2         bool
+}
+
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		MaxConnsPerHost:     0,
+		IdleConnTimeout:     90 * time.Second,
+		KeepAlive:           30 * time.Second,
+		DialTimeout:         30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		EnableHTTP2:         true,
+	}
+}
+
+func newTransport(opts TransportOptions, tlsConfig *tls.Config) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   opts.DialTimeout,
+		KeepAlive: opts.KeepAlive,
+	}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   opts.EnableHTTP2,
+		MaxIdleConns:        opts.MaxIdleConns,
+		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     opts.MaxConnsPerHost,
+		IdleConnTimeout:     opts.IdleConnTimeout,
+		TLSHandshakeTimeout: opts.TLSHandshakeTimeout,
+		TLSClientConfig:     tlsConfig.Clone(),
+	}
+	if !opts.EnableHTTP2 {
+		// a non-nil, empty map disables the automatic HTTP/2 upgrade
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport
+}
+
+// SetTransportOptions replaces the shared transport of the client. Idle connections of the previous transport are closed.
+func (client *Client) SetTransportOptions(opts TransportOptions) {
+	old := client.transport
+	client.transportOptions = opts
+	if client.tlsConfig == nil {
+		client.tlsConfig = &tls.Config{InsecureSkipVerify: !client.verifyCertificate()}
+	}
+	client.transport = newTransport(opts, client.tlsConfig)
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+}
+
+func (client *Client) GetTransportOptions() TransportOptions {
+	return client.transportOptions
+}
+
+// GetHttpClient returns a http.Client which uses the shared transport and the middlewares of this client. A timeout of 0 means no timeout.
+func (client *Client) GetHttpClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: client.roundTripper(),
+		Timeout:   timeout,
+	}
+}
+
+func (client *Client) CloseIdleConnections() {
+	if client.transport != nil {
+		client.transport.CloseIdleConnections()
+	}
+}
+
+func (client *Client) verifyCertificate() bool {
+	if client.conn != nil {
+		return client.conn.VerifyCertificate()
+	}
+	return true
+}
@@ -1,10 +1,35 @@
 package test
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"math/big"
+	"net"
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -130,6 +155,1556 @@ func TestTimeout(t *testing.T) {
 	agora.Client.CheckConnection()
 }
 
+func TestTLSOptions(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(200)
+	}))
+	defer tlsServer.Close()
+	cert := tlsServer.Certificate()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(hash[:])
+
+	client := http.NewClient(tlsServer.URL, "key", true)
+	err := client.CheckConnection()
+	assert.Assert(t, err != nil, "connection to an untrusted server must fail")
+
+	err = client.SetTLSOptions(http.TLSOptions{CAPEM: caPEM, PinnedSPKIHashes: []string{"sha256/" + pin}})
+	assert.NilError(t, err)
+	assert.NilError(t, client.CheckConnection())
+
+	err = client.SetTLSOptions(http.TLSOptions{CAPEM: caPEM, PinnedSPKIHashes: []string{"AAAA"}})
+	assert.NilError(t, err)
+	assert.Assert(t, client.CheckConnection() != nil, "connection with a wrong pin must fail")
+
+	// an insecure client must not change the certificate check of other clients
+	insecure := http.NewClient(tlsServer.URL, "key", false)
+	assert.NilError(t, insecure.CheckConnection())
+	assert.Assert(t, http.NewClient(tlsServer.URL, "key", true).CheckConnection() != nil)
+}
+
+// selfSignedCert creates a self-signed certificate for 127.0.0.1
+func selfSignedCert(t *testing.T, name string) (*x509.Certificate, tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NilError(t, err)
+	return cert, tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}
+}
+
+func TestTLSPinAppendedCertificate(t *testing.T) {
+	pinnedCert, _ := selfSignedCert(t, "pinned")
+	attackerCert, attackerKeyPair := selfSignedCert(t, "attacker")
+	// the attacker sends its own leaf and appends the (public) pinned certificate
+	attackerKeyPair.Certificate = append(attackerKeyPair.Certificate, pinnedCert.Raw)
+
+	tlsServer := httptest.NewUnstartedServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(200)
+	}))
+	tlsServer.TLS = &tls.Config{Certificates: []tls.Certificate{attackerKeyPair}}
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	hash := sha256.Sum256(pinnedCert.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(hash[:])
+
+	insecure := http.NewClient(tlsServer.URL, "key", false)
+	assert.NilError(t, insecure.SetTLSOptions(http.TLSOptions{PinnedSPKIHashes: []string{pin}}))
+	assert.Assert(t, insecure.CheckConnection() != nil, "a pin must only match the leaf without verification")
+
+	attackerPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: attackerCert.Raw})
+	verified := http.NewClient(tlsServer.URL, "key", true)
+	assert.NilError(t, verified.SetTLSOptions(http.TLSOptions{CAPEM: attackerPEM, PinnedSPKIHashes: []string{pin}}))
+	assert.Assert(t, verified.CheckConnection() != nil, "a pin must only match the verified chain")
+
+	hash = sha256.Sum256(attackerCert.RawSubjectPublicKeyInfo)
+	assert.NilError(t, insecure.SetTLSOptions(http.TLSOptions{PinnedSPKIHashes: []string{base64.StdEncoding.EncodeToString(hash[:])}}))
+	assert.NilError(t, insecure.CheckConnection())
+}
+
+func TestCreateOptions(t *testing.T) {
+	var testHeader atomic.Value
+	tlsServer := httptest.NewTLSServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/api/v1/user/current/", "/api/v1/version/":
+			testHeader.Store(r.Header.Get("X-Test"))
+			w.Write([]byte(`{}`))
+		case "/api/v1/apikey/":
+			w.Write([]byte(`{"key": "the-key"}`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer tlsServer.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+	tlsOptions := http.TLSOptions{CAPEM: caPEM}
+
+	_, err := agora.Create(tlsServer.URL, "key", true)
+	assert.Assert(t, err != nil, "the private CA is not trusted without TLS options")
+
+	a, err := agora.Create(tlsServer.URL, "key", true, agora.WithTLSOptions(tlsOptions))
+	assert.NilError(t, err)
+	assert.NilError(t, a.Client.CheckConnection())
+
+	// the middleware is applied before the connection is checked
+	a, err = agora.CreateWithPassword(tlsServer.URL, "user", "password", true, agora.WithTLSOptions(tlsOptions), agora.WithMiddleware(http.HeaderMiddleware("X-Test", "agora-test")))
+	assert.Equal(t, testHeader.Load(), "agora-test")
+	assert.NilError(t, err)
+	apiKey, err := a.GetApiKey()
+	assert.NilError(t, err)
+	assert.Equal(t, apiKey, "the-key")
+}
+
+func TestAPIError(t *testing.T) {
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/user/current/" {
+			w.WriteHeader(401)
+			w.Write([]byte(`{"detail": "Invalid api key."}`))
+			return
+		}
+		w.WriteHeader(404)
+		w.Write([]byte(`{"detail": "Not found."}`))
+	}))
+	defer testServer.Close()
+
+	_, err := agora.Create(testServer.URL, "wrong", false)
+	assert.Assert(t, errors.Is(err, agora.ErrUnauthorized))
+	assert.Assert(t, !errors.Is(err, agora.ErrNotFound))
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	_, err = a.GetProject(1)
+	assert.Assert(t, errors.Is(err, agora.ErrNotFound))
+	var apiError *agora.APIError
+	assert.Assert(t, errors.As(err, &apiError))
+	assert.Equal(t, apiError.StatusCode, 404)
+	assert.Equal(t, apiError.Method, "GET")
+	assert.Equal(t, apiError.Detail, "Not found.")
+}
+
+func TestRetry(t *testing.T) {
+	nrRequests := 0
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		nrRequests += 1
+		if nrRequests < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte(`{"id": 3, "name": "test"}`))
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	policy := http.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	a.SetRetryPolicy(policy)
+	project, err := a.GetProject(3)
+	assert.NilError(t, err)
+	assert.Equal(t, project.ID, 3)
+	assert.Equal(t, nrRequests, 3)
+
+	// Retry-After is capped at MaxBackoff
+	nrRequests = 1
+	policy.MaxBackoff = 10 * time.Millisecond
+	a.SetRetryPolicy(policy)
+	testServer.Config.Handler = nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		nrRequests += 1
+		if nrRequests < 3 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte(`{"id": 3, "name": "test"}`))
+	})
+	start := time.Now()
+	_, err = a.GetProject(3)
+	assert.NilError(t, err)
+	assert.Assert(t, time.Since(start) < 5*time.Second)
+
+	nrRequests = 0
+	a.SetRetryPolicy(http.NoRetryPolicy())
+	_, err = a.GetProject(3)
+	assert.Assert(t, errors.Is(err, agora.ErrServerUnavailable))
+	assert.Equal(t, nrRequests, 1)
+}
+
+func TestRetryTimeout(t *testing.T) {
+	var nrRequests int32
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if atomic.AddInt32(&nrRequests, 1) == 1 {
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.Write([]byte(`{"id": 3, "name": "test"}`))
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	policy := http.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	a.SetRetryPolicy(policy)
+	a.SetTimeout(100 * time.Millisecond)
+	project, err := a.GetProject(3)
+	assert.NilError(t, err)
+	assert.Equal(t, project.ID, 3)
+	assert.Equal(t, atomic.LoadInt32(&nrRequests), int32(2))
+
+	// a cancelled context of the caller is not retried
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	atomic.StoreInt32(&nrRequests, 0)
+	_, err = a.GetProjectContext(ctx, 3)
+	assert.Assert(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, atomic.LoadInt32(&nrRequests), int32(0))
+}
+
+func TestDo(t *testing.T) {
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.Method {
+		case "PATCH":
+			var data map[string]string
+			json.NewDecoder(r.Body).Decode(&data)
+			w.Write([]byte(fmt.Sprintf(`{"id": 5, "name": "%s"}`, data["name"])))
+		case "DELETE":
+			w.WriteHeader(204)
+		default:
+			w.WriteHeader(405)
+		}
+	}))
+	defer testServer.Close()
+
+	client := http.NewClient(testServer.URL, "key", false)
+	var folder models.Folder
+	err := client.Do("PATCH", "/api/v2/folder/5/", map[string]string{"name": "renamed"}, &folder)
+	assert.NilError(t, err)
+	assert.Equal(t, folder.Name, "renamed")
+	assert.Assert(t, folder.Client == client)
+
+	assert.NilError(t, client.Do("DELETE", "/api/v2/folder/5/", nil, nil))
+	assert.Assert(t, client.Do("PUT", "/api/v2/folder/5/", nil, nil) != nil)
+}
+
+func TestPaginator(t *testing.T) {
+	nrItems := 25
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		var results []map[string]interface{}
+		for i := offset; i < offset+limit && i < nrItems; i++ {
+			results = append(results, map[string]interface{}{"id": i, "content_type": "folder", "folder": 1})
+		}
+		var next *string
+		if offset+limit < nrItems {
+			// the host of the next link is ignored, e.g. the internal host behind a proxy
+			nextUrl := fmt.Sprintf("http://agora.internal:8000%s?limit=%d&offset=%d", r.URL.Path, limit, offset+limit)
+			next = &nextUrl
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"count": nrItems, "next": next, "results": results})
+	}))
+	defer testServer.Close()
+
+	client := http.NewClient(testServer.URL, "key", false)
+	folder := models.Folder{ID: 1, BaseModel: http.BaseModel{Client: client}}
+	paginator := folder.IterateItems(context.Background(), 10)
+	count, err := paginator.Count()
+	assert.NilError(t, err)
+	assert.Equal(t, count, nrItems)
+	i := 0
+	for paginator.Next() {
+		assert.Equal(t, paginator.Value().ID, i)
+		assert.Assert(t, paginator.Value().Client == client)
+		i += 1
+	}
+	assert.NilError(t, paginator.Err())
+	assert.Equal(t, i, nrItems)
+
+	items, err := folder.GetItems()
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), nrItems)
+}
+
+func TestCustomConnection(t *testing.T) {
+	nrChunks := 0
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(401)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/import/":
+			w.Write([]byte(`{"id": 1}`))
+		case "/api/v1/import/1/upload/":
+			nrChunks += 1
+		}
+	}))
+	defer testServer.Close()
+
+	_, err := agora.CreateWithConnection(http.NewTokenConnection(testServer.URL, "wrong", false))
+	assert.Assert(t, errors.Is(err, agora.ErrUnauthorized))
+
+	a, err := agora.CreateWithConnection(http.NewTokenConnection(testServer.URL, "secret", false))
+	assert.NilError(t, err)
+
+	tempDir, err := os.MkdirTemp("", "agora_test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tempDir)
+	path := filepath.Join(tempDir, "file.txt")
+	assert.NilError(t, os.WriteFile(path, []byte("content"), 0644))
+	uploadFile, err := models.NewUploadFile(path, nil)
+	assert.NilError(t, err)
+
+	importPackage, err := a.NewImportPackage()
+	assert.NilError(t, err)
+	progressChan := make(chan models.UploadProgress)
+	go func() {
+		for range progressChan {
+		}
+	}()
+	err = importPackage.Upload([]models.UploadFile{uploadFile}, progressChan)
+	close(progressChan)
+	assert.NilError(t, err)
+	assert.Equal(t, len(importPackage.UploadFailed), 0)
+	assert.Equal(t, nrChunks, 1)
+}
+
+func TestSessionConnection(t *testing.T) {
+	var mutex sync.Mutex
+	nrLogins := 0
+	session := ""
+	csrfToken := "csrf1"
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch {
+		case r.URL.Path == "/accounts/login/" && r.Method == "GET":
+			nethttp.SetCookie(w, &nethttp.Cookie{Name: "csrftoken", Value: csrfToken, Path: "/"})
+		case r.URL.Path == "/accounts/login/" && r.Method == "POST":
+			nrLogins += 1
+			session = fmt.Sprintf("session%d", nrLogins)
+			nethttp.SetCookie(w, &nethttp.Cookie{Name: "sessionid", Value: session, Path: "/"})
+		default:
+			cookie, err := r.Cookie("sessionid")
+			if err != nil || cookie.Value != session {
+				w.WriteHeader(403)
+				return
+			}
+			if r.Method == "POST" && r.Header.Get("X-CSRFToken") != csrfToken {
+				w.WriteHeader(403)
+				return
+			}
+			if r.URL.Path == "/api/v2/project/99/" {
+				// the user is not permitted
+				w.WriteHeader(403)
+				return
+			}
+			if r.URL.Path == "/api/v2/project/1/" {
+				// rotate the csrf token
+				csrfToken = "csrf2"
+				nethttp.SetCookie(w, &nethttp.Cookie{Name: "csrftoken", Value: csrfToken, Path: "/"})
+			}
+			w.Write([]byte(`{"id": 1, "name": "test"}`))
+		}
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgoraWithConnection(http.NewSessionConnection(testServer.URL, "user", "password", false))
+	_, err := a.GetProject(1)
+	assert.NilError(t, err)
+	assert.Equal(t, nrLogins, 1)
+
+	// the rotated csrf token is used
+	assert.NilError(t, a.Client.Do("POST", "/api/v2/project/", map[string]string{"name": "test"}, nil))
+	assert.Equal(t, nrLogins, 1)
+
+	// an expired session leads to a new login
+	mutex.Lock()
+	session = "expired"
+	mutex.Unlock()
+	_, err = a.GetProject(2)
+	assert.NilError(t, err)
+	assert.Equal(t, nrLogins, 2)
+
+	// a missing permission does not lead to a login for every request
+	_, err = a.GetProject(99)
+	assert.Assert(t, errors.Is(err, agora.ErrForbidden))
+	_, err = a.GetProject(99)
+	assert.Assert(t, errors.Is(err, agora.ErrForbidden))
+	assert.Assert(t, nrLogins <= 3)
+	_, err = a.GetProject(2)
+	assert.NilError(t, err)
+
+	// the login does not wait for a slot which is held by a request waiting for the login
+	a.SetMaxInFlight(1)
+	mutex.Lock()
+	session = "expired"
+	loginsBefore := nrLogins
+	mutex.Unlock()
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := a.GetProject(2)
+			errs <- err
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the requests did not finish after the session expired")
+	}
+	close(errs)
+	for err := range errs {
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, nrLogins, loginsBefore+1)
+}
+
+func TestMiddleware(t *testing.T) {
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Write([]byte(fmt.Sprintf(`{"id": 3, "name": "%s"}`, r.Header.Get("User-Agent"))))
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	nrFaults := 0
+	var order []string
+	a.Use(
+		http.UserAgentMiddleware("agora-test"),
+		func(next nethttp.RoundTripper) nethttp.RoundTripper {
+			return http.RoundTripperFunc(func(req *nethttp.Request) (*nethttp.Response, error) {
+				order = append(order, "fault")
+				// inject one transient failure which is then retried
+				if nrFaults == 0 {
+					nrFaults += 1
+					return nil, errors.New("injected fault")
+				}
+				return next.RoundTrip(req)
+			})
+		},
+		http.TimingMiddleware(func(req *nethttp.Request, resp *nethttp.Response, err error, duration time.Duration) {
+			order = append(order, "timing")
+		}),
+	)
+	policy := http.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	a.SetRetryPolicy(policy)
+
+	project, err := a.GetProject(3)
+	assert.NilError(t, err)
+	assert.Equal(t, project.Name, "agora-test")
+	assert.DeepEqual(t, order, []string{"fault", "fault", "timing"})
+}
+
+func TestRateLimit(t *testing.T) {
+	var mutex sync.Mutex
+	inFlight := 0
+	maxInFlight := 0
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		mutex.Lock()
+		inFlight += 1
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mutex.Lock()
+		inFlight -= 1
+		mutex.Unlock()
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	a.SetMaxInFlight(2)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			folder, err := a.GetFolder(1)
+			assert.NilError(t, err)
+			// models share the limits of the instance they were created from
+			assert.Assert(t, folder.Client == a.Client)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, maxInFlight, 2)
+
+	a.SetMaxInFlight(0)
+	a.SetRateLimit(50, 1)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		_, err := a.GetFolder(1)
+		assert.NilError(t, err)
+	}
+	assert.Assert(t, time.Since(start) >= 100*time.Millisecond)
+}
+
+func TestDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("agora"), 1000)
+	hash := sha1.Sum(content)
+	var ranges []string
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		nethttp.ServeContent(w, r, "file.dat", time.Time{}, bytes.NewReader(content))
+	}))
+	defer testServer.Close()
+
+	tempDir, err := os.MkdirTemp("", "agora_test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tempDir)
+	dest := filepath.Join(tempDir, "file.dat")
+	// simulate an interrupted download
+	assert.NilError(t, os.WriteFile(dest+models.PartialDownloadSuffix, content[:1234], 0644))
+
+	client := http.NewClient(testServer.URL, "key", false)
+	datafile := models.Datafile{ID: 1, Size: int64(len(content)), Sha1: hex.EncodeToString(hash[:]), BaseModel: http.BaseModel{Client: client}}
+	err = datafile.Download(dest, nil)
+	assert.NilError(t, err)
+	downloaded, err := os.ReadFile(dest)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, downloaded, content)
+	assert.DeepEqual(t, ranges, []string{"bytes=1234-"})
+
+	// an identical file is not downloaded again
+	assert.NilError(t, datafile.Download(dest, nil))
+	assert.Equal(t, len(ranges), 1)
+
+	datafile.Sha1 = "0000"
+	err = datafile.Download(filepath.Join(tempDir, "corrupt.dat"), nil)
+	assert.Assert(t, errors.Is(err, models.ErrChecksumMismatch))
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := bytes.Repeat([]byte("agora"), 1000)
+	hash := sha1.Sum(content)
+	var ranges []string
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if len(ranges) == 1 {
+			// the connection breaks in the middle of the body
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:2000])
+			w.(nethttp.Flusher).Flush()
+			panic(nethttp.ErrAbortHandler)
+		}
+		nethttp.ServeContent(w, r, "file.dat", time.Time{}, bytes.NewReader(content))
+	}))
+	defer testServer.Close()
+
+	tempDir, err := os.MkdirTemp("", "agora_test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	client := http.NewClient(testServer.URL, "key", false)
+	policy := http.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	client.SetRetryPolicy(policy)
+	datafile := models.Datafile{ID: 1, Size: int64(len(content)), Sha1: hex.EncodeToString(hash[:]), BaseModel: http.BaseModel{Client: client}}
+	dest := filepath.Join(tempDir, "file.dat")
+	assert.NilError(t, datafile.Download(dest, nil))
+	downloaded, err := os.ReadFile(dest)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, downloaded, content)
+	assert.DeepEqual(t, ranges, []string{"", "bytes=2000-"})
+
+	// a local error is not retried
+	ranges = nil
+	dest = filepath.Join(tempDir, "local.dat")
+	assert.NilError(t, os.Mkdir(dest+models.PartialDownloadSuffix, 0755))
+	err = datafile.Download(dest, nil)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, len(ranges), 1)
+}
+
+func TestSeriesDownload(t *testing.T) {
+	responses := map[string]string{
+		"/api/v2/series/20/datasets/":   `[{"id": 30, "name": "DS"}, {"id": 31, "name": "DS"}]`,
+		"/api/v2/dataset/30/datafiles/": `[{"id": 40, "path": "IM_0001"}]`,
+		"/api/v2/dataset/31/datafiles/": `[{"id": 41, "path": "IM_0001"}]`,
+		"/api/v2/dataset/32/datafiles/": `[{"id": 42, "path": "IM:1"}, {"id": 43, "path": "IM_1"}]`,
+	}
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if response, ok := responses[r.URL.Path]; ok {
+			w.Write([]byte(response))
+		} else if strings.HasPrefix(r.URL.Path, "/api/v2/datafile/") {
+			w.Write([]byte(r.URL.Path))
+		} else {
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+
+	tempDir, err := os.MkdirTemp("", "agora_test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	client := http.NewClient(testServer.URL, "key", false)
+	series := models.Series{ID: 20, BaseModel: http.BaseModel{Client: client}}
+	assert.NilError(t, series.Download(tempDir, nil))
+	for path, content := range map[string]string{filepath.Join("DS", "IM_0001"): "/api/v2/datafile/40/download/", filepath.Join("DS_31", "IM_0001"): "/api/v2/datafile/41/download/"} {
+		data, err := os.ReadFile(filepath.Join(tempDir, path))
+		assert.NilError(t, err)
+		assert.Equal(t, string(data), content)
+	}
+
+	// datafiles of a dataset which map to the same file are not downloaded
+	dataset := models.Dataset{ID: 32, BaseModel: http.BaseModel{Client: client}}
+	err = dataset.Download(filepath.Join(tempDir, "collision"), nil)
+	assert.Assert(t, errors.Is(err, models.ErrDownloadCollision))
+	_, err = os.Stat(filepath.Join(tempDir, "collision"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestFolderDownload(t *testing.T) {
+	content := []byte("datafile content")
+	hash := sha1.Sum(content)
+	sha := hex.EncodeToString(hash[:])
+	responses := map[string]string{
+		"/api/v2/folder/1/items/":       `[{"id": 1, "content_type": "folder", "object_id": 2, "content_object": {"id": 2, "name": "Sub"}}, {"id": 2, "content_type": "exam", "object_id": 10, "content_object": {"id": 10, "name": "Exam: 1"}}]`,
+		"/api/v2/folder/2/items/":       `[{"id": 3, "content_type": "dataset", "object_id": 30, "content_object": {"id": 30, "name": "DS"}}]`,
+		"/api/v2/exam/10/series/":       `[{"id": 20, "name": "Series"}]`,
+		"/api/v2/series/20/datasets/":   `[{"id": 31, "name": "DS2"}]`,
+		"/api/v2/dataset/30/datafiles/": fmt.Sprintf(`[{"id": 40, "path": "a.txt", "size": %d, "sha1": "%s"}]`, len(content), sha),
+		"/api/v2/dataset/31/datafiles/": fmt.Sprintf(`[{"id": 41, "path": "../sub/b.txt", "size": %d, "sha1": "%s"}]`, len(content), sha),
+	}
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if response, ok := responses[r.URL.Path]; ok {
+			w.Write([]byte(response))
+		} else if r.URL.Path == "/api/v2/datafile/40/download/" || r.URL.Path == "/api/v2/datafile/41/download/" {
+			w.Write(content)
+		} else {
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+
+	tempDir, err := os.MkdirTemp("", "agora_test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	client := http.NewClient(testServer.URL, "key", false)
+	folder := models.Folder{ID: 1, BaseModel: http.BaseModel{Client: client}}
+	report, err := folder.DownloadTo(tempDir, 2, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, report.NrFiles, 2)
+	assert.Equal(t, report.NrDownloaded, 2)
+	for _, path := range []string{filepath.Join("Sub", "DS", "a.txt"), filepath.Join("Exam_ 1", "Series", "DS2", "sub", "b.txt")} {
+		_, err := os.Stat(filepath.Join(tempDir, path))
+		assert.NilError(t, err)
+	}
+
+	report, err = folder.DownloadTo(tempDir, 2, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, report.NrSkipped, 2)
+	assert.Equal(t, report.NrDownloaded, 0)
+}
+
+func TestFolderDownloadCollision(t *testing.T) {
+	responses := map[string]string{
+		"/api/v2/folder/1/items/":       `[{"id": 1, "content_type": "series", "object_id": 20, "content_object": {"id": 20, "name": "S"}}]`,
+		"/api/v2/series/20/datasets/":   `[{"id": 30, "name": "DS"}, {"id": 31, "name": "DS"}]`,
+		"/api/v2/dataset/30/datafiles/": `[{"id": 40, "path": "IM_0001"}, {"id": 42, "path": "IM_0002"}, {"id": 43, "path": "./im_0002"}]`,
+		"/api/v2/dataset/31/datafiles/": `[{"id": 41, "path": "IM_0001"}]`,
+	}
+	nrDownloads := 0
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if response, ok := responses[r.URL.Path]; ok {
+			w.Write([]byte(response))
+		} else if strings.HasPrefix(r.URL.Path, "/api/v2/datafile/") {
+			nrDownloads += 1
+			w.Write([]byte(r.URL.Path))
+		} else {
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+
+	tempDir, err := os.MkdirTemp("", "agora_test")
+	assert.NilError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	client := http.NewClient(testServer.URL, "key", false)
+	folder := models.Folder{ID: 1, BaseModel: http.BaseModel{Client: client}}
+	// datafiles 42 and 43 end up in the same file, nothing must be downloaded
+	_, err = folder.DownloadTo(tempDir, 2, nil)
+	assert.Assert(t, errors.Is(err, models.ErrDownloadCollision))
+	assert.Equal(t, nrDownloads, 0)
+
+	// datasets with the same name get their own directories
+	responses["/api/v2/dataset/30/datafiles/"] = `[{"id": 40, "path": "IM_0001"}]`
+	report, err := folder.DownloadTo(tempDir, 2, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, report.NrDownloaded, 2)
+	for path, content := range map[string]string{filepath.Join("S", "DS", "IM_0001"): "/api/v2/datafile/40/download/", filepath.Join("S", "DS_31", "IM_0001"): "/api/v2/datafile/41/download/"} {
+		data, err := os.ReadFile(filepath.Join(tempDir, path))
+		assert.NilError(t, err)
+		assert.Equal(t, string(data), content)
+	}
+}
+
+func TestFolderItemContentObject(t *testing.T) {
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Write([]byte(`[{"id": 1, "content_type": "folder", "object_id": 2, "content_object": {"id": 2, "name": "Sub"}},
+			{"id": 2, "content_type": "exam", "object_id": 10, "content_object": {"id": 10, "name": "Exam"}},
+			{"id": 3, "content_type": "dataset", "object_id": 30, "content_object": {"id": 30, "name": "DS", "type": 100}},
+			{"id": 4, "content_type": "unknown", "object_id": 40, "content_object": {"id": 40}},
+			{"id": 5, "content_type": "dataset", "object_id": 50, "content_object": null}]`))
+	}))
+	defer testServer.Close()
+
+	client := http.NewClient(testServer.URL, "key", false)
+	folder := models.Folder{ID: 1, BaseModel: http.BaseModel{Client: client}}
+	items, err := folder.GetItems()
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 5)
+
+	study, ok := items[1].AsStudy()
+	assert.Assert(t, ok)
+	assert.Equal(t, study.Name, "Exam")
+	assert.Equal(t, study.Client, client)
+	_, ok = items[1].AsDataset()
+	assert.Assert(t, !ok)
+	_, ok = items[3].ContentObject.(map[string]interface{})
+	assert.Assert(t, ok)
+	assert.Assert(t, items[4].ContentObject == nil)
+	assert.Equal(t, items[4].ObjectID, 50)
+
+	// typed objects are decoded the same way
+	var object models.TypedObject
+	assert.NilError(t, json.Unmarshal([]byte(`{"content_type": "series", "object_id": 20}`), &object))
+	assert.Assert(t, object.ContentObject == nil)
+	assert.NilError(t, json.Unmarshal([]byte(`{"content_type": "series", "object_id": 20, "content_object": {"id": 20, "name": "S"}}`), &object))
+	series, ok := object.AsSeries()
+	assert.Assert(t, ok)
+	assert.Equal(t, series.Name, "S")
+
+	datasets, err := folder.GetDatasets()
+	assert.NilError(t, err)
+	assert.Equal(t, len(datasets), 1)
+	assert.Equal(t, datasets[0].Type, models.DatasetType(100))
+	assert.Equal(t, datasets[0].Client, client)
+
+	folders, err := folder.GetFolders()
+	assert.NilError(t, err)
+	assert.Equal(t, folders[0].Name, "Sub")
+	assert.Equal(t, folders[0].Client, client)
+}
+
+// fakeFolderServer keeps a folder tree in memory and implements the folder endpoints
+type fakeFolderServer struct {
+	names    map[int]string
+	parents  map[int]int
+	nrCreate int
+}
+
+func newFakeFolderServer() *fakeFolderServer {
+	return &fakeFolderServer{names: map[int]string{1: "root"}, parents: map[int]int{}}
+}
+
+func (f *fakeFolderServer) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var id int
+	var action string
+	fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/api/v2/folder/"), "%d/%s", &id, &action)
+	if _, ok := f.names[id]; !ok {
+		w.WriteHeader(404)
+		return
+	}
+	switch {
+	case action == "items/":
+		var items []map[string]interface{}
+		for child, parent := range f.parents {
+			if parent == id {
+				items = append(items, map[string]interface{}{"id": child, "content_type": "folder", "object_id": child, "folder": id,
+					"content_object": map[string]interface{}{"id": child, "name": f.names[child]}})
+			}
+		}
+		json.NewEncoder(w).Encode(items)
+	case action == "breadcrumb/":
+		var breadcrumb []map[string]interface{}
+		for cur, ok := id, true; ok; cur, ok = f.parents[cur] {
+			breadcrumb = append([]map[string]interface{}{{"id": cur, "name": f.names[cur]}}, breadcrumb...)
+		}
+		json.NewEncoder(w).Encode(breadcrumb)
+	case action == "new/" && r.Method == "POST":
+		var data map[string]string
+		json.NewDecoder(r.Body).Decode(&data)
+		f.nrCreate += 1
+		newId := len(f.names) + 1
+		f.names[newId] = data["name"]
+		f.parents[newId] = id
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": newId, "name": data["name"]})
+	case action == "" && r.Method == "PATCH":
+		var data map[string]string
+		json.NewDecoder(r.Body).Decode(&data)
+		f.names[id] = data["name"]
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "name": data["name"]})
+	case action == "" && r.Method == "DELETE":
+		delete(f.names, id)
+		delete(f.parents, id)
+		w.WriteHeader(204)
+	case action == "" && r.Method == "GET":
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "name": f.names[id]})
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+func TestFolderOperations(t *testing.T) {
+	fakeServer := newFakeFolderServer()
+	testServer := httptest.NewServer(fakeServer)
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	root, err := a.GetFolder(1)
+	assert.NilError(t, err)
+
+	folder, err := root.GetOrCreatePath("2024/S012/Session2")
+	assert.NilError(t, err)
+	assert.Equal(t, folder.Name, "Session2")
+	assert.Equal(t, fakeServer.nrCreate, 3)
+
+	again, err := root.GetOrCreatePath("/2024/S012/Session2/")
+	assert.NilError(t, err)
+	assert.Equal(t, again.ID, folder.ID)
+	assert.Equal(t, fakeServer.nrCreate, 3)
+
+	assert.NilError(t, folder.Rename("Session3"))
+	assert.Equal(t, folder.Name, "Session3")
+	assert.NilError(t, folder.Delete())
+	_, err = a.GetFolder(folder.ID)
+	assert.Assert(t, errors.Is(err, agora.ErrNotFound))
+}
+
+func TestFolderPath(t *testing.T) {
+	fakeServer := newFakeFolderServer()
+	testServer := httptest.NewServer(fakeServer)
+	defer testServer.Close()
+
+	client := http.NewClient(testServer.URL, "key", false)
+	project := models.Project{ID: 1, RootFolder: 1, BaseModel: http.BaseModel{Client: client}}
+	root := models.Folder{ID: 1, BaseModel: http.BaseModel{Client: client}}
+	session, err := root.GetOrCreatePath("Subjects/S012/Session2")
+	assert.NilError(t, err)
+
+	folder, err := project.GetFolderByPath("/Subjects/S012/Session2")
+	assert.NilError(t, err)
+	assert.Equal(t, folder.ID, session.ID)
+
+	_, err = project.GetFolderByPath("/Subjects/S013")
+	assert.Assert(t, errors.Is(err, models.ErrFolderNotFound))
+	assert.Assert(t, errors.Is(err, agora.ErrNotFound))
+
+	subjects, err := root.Resolve("Subjects")
+	assert.NilError(t, err)
+	_, err = subjects.CreateFolder("S012")
+	assert.NilError(t, err)
+	_, err = root.Resolve("Subjects/S012/Session2")
+	assert.Assert(t, errors.Is(err, models.ErrAmbiguousPath))
+
+	breadcrumb, err := session.Path()
+	assert.NilError(t, err)
+	var names []string
+	for _, f := range breadcrumb {
+		names = append(names, f.Name)
+	}
+	assert.DeepEqual(t, names, []string{"root", "Subjects", "S012", "Session2"})
+}
+
+func TestFolderMoveAndLink(t *testing.T) {
+	var requests []string
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+strings.TrimSpace(string(body)))
+		switch r.URL.Path {
+		case "/api/v2/folder/2/move/":
+			w.WriteHeader(204)
+		case "/api/v2/folder/2/copy/":
+			w.Write([]byte(`[{"id": 200, "folder": 2, "content_type": "exam", "object_id": 11, "content_object": {"id": 11, "name": "Copy"}}]`))
+		case "/api/v2/folder/2/link/":
+			var data struct {
+				Items []map[string]interface{} `json:"items"`
+			}
+			json.Unmarshal(body, &data)
+			var items []map[string]interface{}
+			for i, link := range data.Items {
+				items = append(items, map[string]interface{}{"id": 100 + i, "folder": 2, "is_link": true,
+					"content_type": link["content_type"], "object_id": link["object_id"], "content_object": map[string]interface{}{"id": link["object_id"]}})
+			}
+			json.NewEncoder(w).Encode(items)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+
+	client := http.NewClient(testServer.URL, "key", false)
+	target := models.Folder{ID: 2, BaseModel: http.BaseModel{Client: client}}
+	item := models.FolderItem{ID: 5, Folder: 1, ContentType: models.ContentTypeExam, ObjectID: 10, BaseModel: http.BaseModel{Client: client}}
+
+	assert.NilError(t, item.MoveTo(&target))
+	assert.Equal(t, item.Folder, 2)
+
+	copied, err := item.CopyTo(&target)
+	assert.NilError(t, err)
+	copiedStudy, ok := copied.AsStudy()
+	assert.Assert(t, ok)
+	assert.Equal(t, copiedStudy.ID, 11)
+
+	link, err := item.LinkTo(&target)
+	assert.NilError(t, err)
+	assert.Assert(t, link.IsLink)
+	study, ok := link.AsStudy()
+	assert.Assert(t, ok)
+	assert.Equal(t, study.ID, 10)
+
+	dataset := models.Dataset{ID: 30}
+	links, err := target.AddLinks([]models.Linkable{&dataset, &item})
+	assert.NilError(t, err)
+	assert.Equal(t, len(links), 2)
+	assert.Equal(t, links[0].ContentType, models.ContentTypeDataset)
+
+	assert.DeepEqual(t, requests, []string{
+		`POST /api/v2/folder/2/move/ {"items":[5]}`,
+		`POST /api/v2/folder/2/copy/ {"items":[5]}`,
+		`POST /api/v2/folder/2/link/ {"items":[{"content_type":"exam","object_id":10}]}`,
+		`POST /api/v2/folder/2/link/ {"items":[{"content_type":"dataset","object_id":30},{"content_type":"exam","object_id":10}]}`,
+	})
+}
+
+func TestProjectMembers(t *testing.T) {
+	var requests []string
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+strings.TrimSpace(string(body)))
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v2/project/":
+			w.Write([]byte(`{"id": 3, "name": "Study Team", "description": "cohort", "memberships": [{"id": 1, "user": 1, "role": 1, "project": 3}]}`))
+		case "PATCH /api/v2/project/3/":
+			w.Write(body)
+		case "POST /api/v2/project/3/members/":
+			w.Write([]byte(`{"id": 2, "user": 7, "role": 2, "project": 3}`))
+		case "PATCH /api/v2/project/3/members/2/":
+			w.Write([]byte(`{"id": 2, "user": 7, "role": 3, "project": 3}`))
+		case "DELETE /api/v2/project/3/members/2/":
+			w.WriteHeader(204)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	project, err := a.CreateProject("Study Team", "cohort")
+	assert.NilError(t, err)
+	assert.Equal(t, project.Memberships[0].Role, models.RoleAdmin)
+
+	project.Name = "Study Team 2"
+	assert.NilError(t, project.Update())
+	assert.Equal(t, project.Name, "Study Team 2")
+
+	membership, err := project.AddMember(7, models.RoleMember)
+	assert.NilError(t, err)
+	assert.Equal(t, membership.Role, models.RoleMember)
+	assert.Equal(t, len(project.Memberships), 2)
+
+	assert.NilError(t, project.SetRole(7, models.RoleGuest))
+	membership, err = project.GetMembership(7)
+	assert.NilError(t, err)
+	assert.Equal(t, membership.Role.String(), "guest")
+
+	assert.NilError(t, project.RemoveMember(7))
+	assert.Equal(t, len(project.Memberships), 1)
+	err = project.RemoveMember(7)
+	assert.Assert(t, errors.Is(err, agora.ErrNotFound))
+
+	assert.DeepEqual(t, requests[3:], []string{
+		`PATCH /api/v2/project/3/members/2/ {"role":3}`,
+		`DELETE /api/v2/project/3/members/2/ `,
+	})
+}
+
+func TestUsers(t *testing.T) {
+	responses := map[string]string{
+		"/api/v1/user/current/": `{"id": 1, "username": "admin", "first_name": "Ada", "last_name": "Lovelace"}`,
+		"/api/v1/user/1/":       `{"id": 1, "username": "admin"}`,
+		"/api/v1/user/7/":       `{"id": 7, "username": "jdoe"}`,
+		"/api/v1/user/":         `{"count": 2, "next": null, "results": [{"id": 1, "username": "admin"}, {"id": 7, "username": "jdoe"}]}`,
+		"/api/v1/group/":        `[{"id": 1, "name": "Radiology", "users": [7]}]`,
+		"/api/v2/project/3/":    `{"id": 3, "name": "Project", "owner": 1, "memberships": [{"id": 2, "user": 7, "role": 2, "project": 3}]}`,
+	}
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if response, ok := responses[r.URL.Path]; ok {
+			w.Write([]byte(response))
+		} else {
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	user, err := a.GetCurrentUser()
+	assert.NilError(t, err)
+	assert.Equal(t, user.DisplayName(), "Ada Lovelace")
+
+	users, err := a.GetUsers()
+	assert.NilError(t, err)
+	assert.Equal(t, len(users), 2)
+
+	groups, err := a.GetGroups()
+	assert.NilError(t, err)
+	members, err := groups[0].GetUsers()
+	assert.NilError(t, err)
+	assert.Equal(t, members[0].DisplayName(), "jdoe")
+
+	project, err := a.GetProject(3)
+	assert.NilError(t, err)
+	owner, err := project.GetOwner()
+	assert.NilError(t, err)
+	assert.Equal(t, owner.Username, "admin")
+	member, err := project.Memberships[0].GetUser()
+	assert.NilError(t, err)
+	assert.Equal(t, member.Username, "jdoe")
+
+	_, err = a.GetUser(8)
+	assert.Assert(t, errors.Is(err, agora.ErrNotFound))
+}
+
+func TestTaskRun(t *testing.T) {
+	var runRequest string
+	nrPolls := 0
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/api/v2/task/":
+			w.Write([]byte(`[{"id": 5, "name": "Recon", "inputs": [{"key": "raw", "type": "dataset", "required": true}]}]`))
+		case "/api/v2/task/5/run/":
+			body, _ := io.ReadAll(r.Body)
+			runRequest = string(body)
+			w.Write([]byte(`{"id": 9, "task": 5, "state": 1}`))
+		case "/api/v2/taskinfo/9/":
+			nrPolls += 1
+			if nrPolls < 2 {
+				w.Write([]byte(`{"id": 9, "task": 5, "state": 2, "progress": 50}`))
+			} else {
+				w.Write([]byte(`{"id": 9, "task": 5, "state": 3, "progress": 100}`))
+			}
+		case "/api/v2/taskinfo/9/log/":
+			w.Write([]byte("reconstruction done\n"))
+		case "/api/v2/taskinfo/9/results/":
+			w.Write([]byte(`[{"content_type": "dataset", "object_id": 31, "content_object": {"id": 31, "name": "Recon"}}]`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+	models.TASK_POLL_INTERVAL = 10 * time.Millisecond
+	defer func() { models.TASK_POLL_INTERVAL = 2 * time.Second }()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	tasks, err := a.GetTasks()
+	assert.NilError(t, err)
+	assert.Equal(t, tasks[0].Inputs[0].Key, "raw")
+
+	raw := models.Dataset{ID: 30}
+	series := models.Series{ID: 20}
+	taskInfo, err := tasks[0].Run(map[string]any{"raw": &raw, "iterations": 3}, &series)
+	assert.NilError(t, err)
+	assert.Equal(t, runRequest, `{"inputs":{"iterations":3,"raw":{"content_type":"dataset","object_id":30}},"target":{"content_type":"series","object_id":20}}`)
+
+	// values and slices of models are sent as references as well
+	inputs := map[string]any{"raw": raw, "all": []models.Dataset{raw, {ID: 31}}, "pointers": []*models.Dataset{&raw}, "sizes": []int{1, 2}}
+	_, err = tasks[0].Run(inputs, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, runRequest, `{"inputs":{"all":[{"content_type":"dataset","object_id":30},{"content_type":"dataset","object_id":31}],"pointers":[{"content_type":"dataset","object_id":30}],"raw":{"content_type":"dataset","object_id":30},"sizes":[1,2]}}`)
+	_, err = tasks[0].Run(map[string]any{"raw": []*models.Dataset{nil}}, nil)
+	assert.ErrorContains(t, err, "raw")
+
+	progressChan := make(chan models.UploadProgress, 10)
+	assert.NilError(t, taskInfo.Wait(progressChan))
+	assert.Equal(t, taskInfo.State, models.TaskStateFinished)
+	assert.Equal(t, len(progressChan), 2)
+
+	log, err := taskInfo.GetLog()
+	assert.NilError(t, err)
+	assert.Equal(t, log, "reconstruction done\n")
+	results, err := taskInfo.GetResults()
+	assert.NilError(t, err)
+	dataset, ok := results[0].AsDataset()
+	assert.Assert(t, ok)
+	assert.Equal(t, dataset.Client, a.Client)
+}
+
+func TestHosts(t *testing.T) {
+	var runRequest string
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/api/v2/host/":
+			w.Write([]byte(`[{"id": 1, "name": "gpu01", "is_online": false, "last_seen": "2024-03-01T10:00:00Z", "capabilities": ["gpu"]},
+				{"id": 2, "name": "gpu02", "is_online": true, "last_seen": "2024-03-02T10:00:00Z", "capabilities": ["gpu", "matlab"]}]`))
+		case "/api/v2/task/5/run/":
+			body, _ := io.ReadAll(r.Body)
+			runRequest = string(body)
+			w.Write([]byte(`{"id": 9, "task": 5, "state": 1, "host": 2}`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	hosts, err := a.GetHosts()
+	assert.NilError(t, err)
+	assert.Equal(t, len(hosts), 2)
+	assert.Equal(t, hosts[0].LastSeen.Day(), 1)
+
+	var selected *models.Host
+	for i := range hosts {
+		if hosts[i].Online && hosts[i].HasCapability("matlab") {
+			selected = &hosts[i]
+		}
+	}
+	assert.Assert(t, selected != nil)
+
+	task := models.Task{ID: 5, BaseModel: http.BaseModel{Client: a.Client}}
+	taskInfo, err := task.RunOnHost(nil, nil, selected)
+	assert.NilError(t, err)
+	assert.Equal(t, *taskInfo.Host, 2)
+	assert.Equal(t, runRequest, `{"inputs":{},"host":2}`)
+}
+
+func TestTags(t *testing.T) {
+	var requests []string
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+strings.TrimSpace(string(body)))
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v2/tag/":
+			w.Write([]byte(`[{"id": 1, "label": "motion"}]`))
+		case "POST /api/v2/tag/":
+			w.Write([]byte(`{"id": 2, "label": "QC passed", "color": "#00ff00"}`))
+		case "POST /api/v2/tag/2/assign/", "POST /api/v2/tag/2/unassign/":
+			w.WriteHeader(204)
+		case "GET /api/v2/tag/2/objects/":
+			assert.Equal(t, r.URL.Query().Get("content_type"), "series")
+			w.Write([]byte(`{"count": 1, "next": null, "results": [{"content_type": "series", "object_id": 20, "content_object": {"id": 20, "name": "T1"}}]}`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	tags, err := a.GetTags()
+	assert.NilError(t, err)
+	assert.Equal(t, tags[0].Label, "motion")
+
+	tag, err := a.CreateTag("QC passed", "#00ff00")
+	assert.NilError(t, err)
+	series := models.Series{ID: 20, BaseModel: http.BaseModel{Client: a.Client}}
+	assert.NilError(t, series.Tag(tag))
+	assert.NilError(t, series.Untag(tag))
+
+	objects, err := a.FindByTag(tag, models.ContentTypeSeries)
+	assert.NilError(t, err)
+	found, ok := objects[0].AsSeries()
+	assert.Assert(t, ok)
+	assert.Equal(t, found.Name, "T1")
+	assert.Equal(t, found.Client, a.Client)
+
+	assert.DeepEqual(t, requests[2:4], []string{
+		`POST /api/v2/tag/2/assign/ {"content_type":"series","object_id":20}`,
+		`POST /api/v2/tag/2/unassign/ {"content_type":"series","object_id":20}`,
+	})
+}
+
+func TestSearch(t *testing.T) {
+	var query url.Values
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path != "/api/v2/search/" {
+			w.WriteHeader(404)
+			return
+		}
+		query = r.URL.Query()
+		w.Write([]byte(`{"count": 2, "next": null, "results": [
+			{"content_type": "exam", "object_id": 10, "content_object": {"id": 10, "name": "Brain"}},
+			{"content_type": "patient", "object_id": 4, "content_object": {"id": 4, "name": "Doe"}}]}`))
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	opts := models.SearchOptions{
+		ContentTypes: []string{models.ContentTypeExam, models.ContentTypePatient},
+		Project:      3,
+		CreatedAfter: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	results, err := a.Search("brain", &opts)
+	assert.NilError(t, err)
+	assert.Equal(t, query.Get("q"), "brain")
+	assert.DeepEqual(t, query["content_type"], []string{"exam", "patient"})
+	assert.Equal(t, query.Get("project"), "3")
+	assert.Equal(t, query.Get("created_after"), "2024-01-01T00:00:00Z")
+	assert.Equal(t, query.Get("owner"), "")
+
+	study, ok := results[0].AsStudy()
+	assert.Assert(t, ok)
+	assert.Equal(t, study.Name, "Brain")
+	patient, ok := results[1].AsPatient()
+	assert.Assert(t, ok)
+	assert.Equal(t, patient.Client, a.Client)
+
+	_, err = a.Search("brain", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(query["content_type"]), 0)
+}
+
+func TestParameters(t *testing.T) {
+	var queryRequest string
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/api/v2/dataset/30/parameters/":
+			if r.URL.Query().Get("offset") == "0" {
+				w.Write([]byte(`{"count": 3, "next": "/api/v2/dataset/30/parameters/?offset=2", "results": [{"name": "EchoTime", "value": 4.6, "unit": "ms"}, {"name": "ScanMode", "value": "3D"}]}`))
+			} else {
+				w.Write([]byte(`{"count": 3, "next": null, "results": [{"name": "RepetitionTime", "value": "9.8"}]}`))
+			}
+		case "/api/v2/parameter/query/":
+			body, _ := io.ReadAll(r.Body)
+			queryRequest = string(body)
+			if r.URL.Query().Get("offset") == "0" {
+				w.Write([]byte(`{"count": 2, "next": "/api/v2/parameter/query/?offset=1", "results": [{"id": 30, "name": "T1"}]}`))
+			} else {
+				w.Write([]byte(`{"count": 2, "next": null, "results": [{"id": 31, "name": "T1"}]}`))
+			}
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	dataset := models.Dataset{ID: 30, BaseModel: http.BaseModel{Client: a.Client}}
+	parameters, err := dataset.GetParameters()
+	assert.NilError(t, err)
+	echoTime, ok := parameters.Get("echotime")
+	assert.Assert(t, ok)
+	value, ok := echoTime.Float()
+	assert.Assert(t, ok)
+	assert.Equal(t, value, 4.6)
+	repetitionTime, _ := parameters.Get("RepetitionTime")
+	value, ok = repetitionTime.Float()
+	assert.Assert(t, ok)
+	assert.Equal(t, value, 9.8)
+	scanMode, _ := parameters.Get("ScanMode")
+	assert.Equal(t, scanMode.String(), "3D")
+	_, ok = parameters.Get("FlipAngle")
+	assert.Assert(t, !ok)
+
+	datasets, err := a.QueryByParameter("EchoTime < 5 and ScanMode = 3D AND Protocol == 'T1 and T2'")
+	assert.NilError(t, err)
+	assert.Equal(t, len(datasets), 2)
+	assert.Equal(t, datasets[1].Client, a.Client)
+	assert.Equal(t, queryRequest, `{"conditions":[{"name":"EchoTime","operator":"\u003c","value":5},{"name":"ScanMode","operator":"=","value":"3D"},{"name":"Protocol","operator":"=","value":"T1 and T2"}]}`)
+
+	// whitespace in quoted values is kept
+	conditions, err := models.ParseParameterFilter("Protocol = \"T1  fast\"  AND\tScanMode = 'a and  b'")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, conditions, []models.ParameterCondition{{Name: "Protocol", Operator: "=", Value: "T1  fast"}, {Name: "ScanMode", Operator: "=", Value: "a and  b"}})
+
+	_, err = models.ParseParameterFilter("EchoTime")
+	assert.Assert(t, errors.Is(err, models.ErrInvalidParameterFilter))
+	_, err = models.ParseParameterFilter("EchoTime < 5 and")
+	assert.Assert(t, errors.Is(err, models.ErrInvalidParameterFilter))
+}
+
+// waitForGoroutines fails the test if the number of goroutines does not drop back to the number before the test
+func waitForGoroutines(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			n := runtime.Stack(buf, true)
+			t.Fatalf("%d goroutines leaked:\n%s", runtime.NumGoroutine()-before, buf[:n])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	chunkSize := models.UPLOAD_CHUCK_SIZE
+	models.UPLOAD_CHUCK_SIZE = 1024
+	defer func() { models.UPLOAD_CHUCK_SIZE = chunkSize }()
+
+	var nrConnections, nrChunks int32
+	testServer := httptest.NewUnstartedServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/api/v2/folder/1/":
+			w.Write([]byte(`{"id": 1, "name": "root"}`))
+		case "/api/v1/import/":
+			w.Write([]byte(`{"id": 1, "state": 1}`))
+		case "/api/v1/import/1/upload/":
+			atomic.AddInt32(&nrChunks, 1)
+			io.Copy(io.Discard, r.Body)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	testServer.Config.ConnState = func(conn net.Conn, state nethttp.ConnState) {
+		if state == nethttp.StateNew {
+			atomic.AddInt32(&nrConnections, 1)
+		}
+	}
+	testServer.Start()
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	for i := 0; i < 5; i++ {
+		_, err := a.GetFolder(1)
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, atomic.LoadInt32(&nrConnections), int32(1))
+
+	// the upload and the models created by the instance use the same pool
+	file := filepath.Join(t.TempDir(), "large.dat")
+	assert.NilError(t, os.WriteFile(file, bytes.Repeat([]byte("a"), 8*1024), 0644))
+	uploadFile, err := models.NewUploadFile(file, nil)
+	assert.NilError(t, err)
+	importPackage, err := a.NewImportPackage()
+	assert.NilError(t, err)
+	progressChan := make(chan models.UploadProgress)
+	go func() {
+		for range progressChan {
+		}
+	}()
+	assert.NilError(t, importPackage.Upload([]models.UploadFile{uploadFile}, progressChan))
+	close(progressChan)
+	assert.Equal(t, atomic.LoadInt32(&nrChunks), int32(8))
+	_, err = a.GetFolder(1)
+	assert.NilError(t, err)
+	assert.Equal(t, atomic.LoadInt32(&nrConnections), int32(1))
+}
+
+func TestUploadCancel(t *testing.T) {
+	goroutinesBefore := runtime.NumGoroutine()
+
+	chunkSize := models.UPLOAD_CHUCK_SIZE
+	models.UPLOAD_CHUCK_SIZE = 1024
+	defer func() { models.UPLOAD_CHUCK_SIZE = chunkSize }()
+
+	dir := t.TempDir()
+	var files []models.UploadFile
+	largeFile := filepath.Join(dir, "large.dat")
+	assert.NilError(t, os.WriteFile(largeFile, bytes.Repeat([]byte("a"), 64*1024), 0644))
+	file, err := models.NewUploadFile(largeFile, nil)
+	assert.NilError(t, err)
+	files = append(files, file)
+	for i := 0; i < 10; i++ {
+		// small files are zipped
+		smallFile := filepath.Join(dir, fmt.Sprintf("small%02d.txt", i))
+		assert.NilError(t, os.WriteFile(smallFile, []byte(fmt.Sprintf("File content %02d\n", i)), 0644))
+		file, err := models.NewUploadFile(smallFile, nil)
+		assert.NilError(t, err)
+		files = append(files, file)
+	}
+
+	var nrChunks int32
+	blocked := make(chan struct{})
+	var blockOnce sync.Once
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/api/v1/import/":
+			w.Write([]byte(`{"id": 1, "state": 1}`))
+		case "/api/v1/import/1/upload/":
+			// the first chunks are accepted, then the server hangs until the client gives up
+			nr := atomic.AddInt32(&nrChunks, 1)
+			io.Copy(io.Discard, r.Body)
+			if nr <= 3 {
+				return
+			}
+			blockOnce.Do(func() { close(blocked) })
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	importPackage, err := a.NewImportPackage()
+	assert.NilError(t, err)
+
+	progressChan := make(chan models.UploadProgress)
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		for range progressChan {
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	uploadErr := make(chan error, 1)
+	go func() {
+		uploadErr <- importPackage.UploadContext(ctx, files, progressChan)
+	}()
+
+	select {
+	case <-blocked:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the upload did not reach the server")
+	}
+	cancel()
+	select {
+	case err = <-uploadErr:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the upload did not stop after the context was cancelled")
+	}
+	assert.Assert(t, errors.Is(err, context.Canceled))
+
+	close(progressChan)
+	<-progressDone
+	testServer.Close()
+	// the remaining chunks of the large file are not sent and no upload worker or zip goroutine is left
+	assert.Assert(t, atomic.LoadInt32(&nrChunks) < 64)
+	waitForGoroutines(t, goroutinesBefore)
+}
+
+func TestImportResult(t *testing.T) {
+	content := []byte("imported")
+	hash := sha1.Sum(content)
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/api/v1/import/":
+			w.Write([]byte(`{"id": 1, "state": 1}`))
+		case "/api/v1/import/1/progress":
+			w.Write([]byte(`{"state": 5, "progress": 100, "tasks": {"count": 1, "finished": 1}}`))
+		case "/api/v1/import/1/result":
+			w.Write([]byte(fmt.Sprintf(`{"datafiles": [{"id": 40, "path": "a.txt", "size": %d, "sha1": "%s", "created": true}]}`, len(content), hex.EncodeToString(hash[:]))))
+		case "/api/v2/datafile/40/download/":
+			w.Write(content)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer testServer.Close()
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	importPackage, err := a.NewImportPackage()
+	assert.NilError(t, err)
+	assert.NilError(t, importPackage.WaitForImport(nil))
+	result, err := importPackage.Result(nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Datafiles), 1)
+
+	// the datafiles of the result can be downloaded
+	dest := filepath.Join(t.TempDir(), "a.txt")
+	assert.NilError(t, result.Datafiles[0].Download(dest, nil))
+	downloaded, err := os.ReadFile(dest)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, downloaded, content)
+
+	datafile := models.Datafile{ID: 40}
+	err = datafile.Download(dest+".copy", nil)
+	assert.Assert(t, errors.Is(err, models.ErrNoClient))
+}
+
+func TestImportWaitCancel(t *testing.T) {
+	goroutinesBefore := runtime.NumGoroutine()
+
+	var nrProgressRequests, nrUpdateRequests int32
+	testServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/api/v1/import/":
+			w.Write([]byte(`{"id": 1, "state": 1}`))
+		case "/api/v1/import/1/complete/":
+			w.WriteHeader(204)
+		case "/api/v1/import/1/":
+			// the import never finishes
+			atomic.AddInt32(&nrUpdateRequests, 1)
+			w.Write([]byte(`{"id": 1, "state": 4, "is_complete": false}`))
+		case "/api/v1/import/1/progress":
+			atomic.AddInt32(&nrProgressRequests, 1)
+			w.Write([]byte(`{"state": 4, "progress": 50, "tasks": {"count": 2, "finished": 1}}`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+
+	a := agora.NewAgora(testServer.URL, "key", false)
+	importPackage, err := a.NewImportPackage()
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	err = importPackage.CompleteContext(ctx, 0, "", false, &wg)
+	assert.NilError(t, err)
+
+	progressChan := make(chan models.UploadProgress)
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- importPackage.WaitForImportContext(ctx, progressChan)
+	}()
+
+	// cancel while polling. Both loops must stop right away and not only at their next poll (every 2 seconds)
+	select {
+	case progress := <-progressChan:
+		assert.Equal(t, progress.Type, models.TypeImportProgress)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the import progress was not polled")
+	}
+	cancel()
+	select {
+	case err = <-waitErr:
+	case <-time.After(time.Second):
+		t.Fatal("WaitForImportContext did not stop after the context was cancelled")
+	}
+	assert.Assert(t, errors.Is(err, context.Canceled))
+
+	// the goroutine started by CompleteContext stops as well
+	waitDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(waitDone)
+	}()
+	select {
+	case <-waitDone:
+	case <-time.After(time.Second):
+		t.Fatal("the completion wait did not stop after the context was cancelled")
+	}
+	assert.Assert(t, atomic.LoadInt32(&nrUpdateRequests) > 0)
+
+	// nothing is polled anymore
+	nrProgress := atomic.LoadInt32(&nrProgressRequests)
+	nrUpdate := atomic.LoadInt32(&nrUpdateRequests)
+	time.Sleep(2500 * time.Millisecond)
+	assert.Equal(t, atomic.LoadInt32(&nrProgressRequests), nrProgress)
+	assert.Equal(t, atomic.LoadInt32(&nrUpdateRequests), nrUpdate)
+
+	testServer.Close()
+	waitForGoroutines(t, goroutinesBefore)
+}
+
 func TestConnectWithPassword(t *testing.T) {
 	username := os.Getenv("AGORA_USERNAME")
 	if len(username) == 0 {
@@ -329,6 +1904,102 @@ func TestStudy(t *testing.T) {
 	assert.Equal(t, study.ID, studyID, "study id is not the same")
 }
 
+func TestSeries(t *testing.T) {
+	apiKey := os.Getenv("AGORA_API_KEY")
+	if len(apiKey) == 0 {
+		t.Errorf("did not find an api key in the environment variable AGORA_API_KEY")
+		return
+	}
+
+	url := server
+	agora, err := agora.Create(url, apiKey, false)
+	if err != nil {
+		t.Errorf("could not connect to Agora: %s", err.Error())
+		return
+	}
+
+	project, err := agora.GetProject(3)
+	if err != nil {
+		t.Errorf("cannot get the project: %s", err.Error())
+		return
+	}
+
+	studies, err := project.GetStudies()
+	if err != nil || len(studies) == 0 {
+		t.Errorf("cannot get the studies")
+		return
+	}
+
+	series, err := studies[0].GetSeries()
+	if err != nil {
+		t.Errorf("cannot get the series: %s", err.Error())
+		return
+	} else if len(series) == 0 {
+		t.Errorf("series is empty")
+		return
+	}
+
+	seriesID := series[0].ID
+	curSeries, err := agora.GetSeries(seriesID)
+	if err != nil {
+		t.Errorf("cannot get the series: %s", err.Error())
+		return
+	}
+	assert.Equal(t, curSeries.ID, seriesID, "series id is not the same")
+}
+
+func TestDataset(t *testing.T) {
+	apiKey := os.Getenv("AGORA_API_KEY")
+	if len(apiKey) == 0 {
+		t.Errorf("did not find an api key in the environment variable AGORA_API_KEY")
+		return
+	}
+
+	url := server
+	agora, err := agora.Create(url, apiKey, false)
+	if err != nil {
+		t.Errorf("could not connect to Agora: %s", err.Error())
+		return
+	}
+
+	project, err := agora.GetProject(3)
+	if err != nil {
+		t.Errorf("cannot get the project: %s", err.Error())
+		return
+	}
+
+	studies, err := project.GetStudies()
+	if err != nil || len(studies) == 0 {
+		t.Errorf("cannot get the studies")
+		return
+	}
+
+	datasets, err := studies[0].GetDatasets()
+	if err != nil {
+		t.Errorf("cannot get the datasets: %s", err.Error())
+		return
+	} else if len(datasets) == 0 {
+		t.Errorf("datasets is empty")
+		return
+	}
+
+	dataset, err := agora.GetDataset(datasets[0].ID)
+	if err != nil {
+		t.Errorf("cannot get the dataset: %s", err.Error())
+		return
+	}
+	assert.Equal(t, dataset.ID, datasets[0].ID, "dataset id is not the same")
+
+	datafiles, err := dataset.GetDatafiles()
+	if err != nil {
+		t.Errorf("cannot get the datafiles: %s", err.Error())
+	} else if len(datafiles) == 0 {
+		t.Errorf("datafiles is empty")
+	} else {
+		assert.Equal(t, datafiles[0].Dataset, dataset.ID, "datafile does not belong to the dataset")
+	}
+}
+
 func TestFolder(t *testing.T) {
 	apiKey := os.Getenv("AGORA_API_KEY")
 	if len(apiKey) == 0 {